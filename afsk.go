@@ -0,0 +1,135 @@
+package fap
+
+import (
+	"fmt"
+	"math"
+)
+
+// Bell 202 AFSK parameters used by 1200 bit/s APRS.
+const (
+	afskBaud  = 1200
+	afskMark  = 1200 // Mark tone frequency in Hz, a one bit in NRZI
+	afskSpace = 2200 // Space tone frequency in Hz
+
+	// The tones must be below the Nyquist frequency
+	afskMinSampleRate = 2*afskSpace + 1
+)
+
+// afskPLLGain is the fraction of the timing error corrected on each
+// transition by the bit clock recovery.
+const afskPLLGain = 0.3
+
+// AFSKDemodulator demodulates 1200 bit/s Bell 202 AFSK audio, as used on
+// VHF APRS, into AX.25 frames. The tones are detected by correlating the
+// audio with the mark and space frequencies over one bit period, the bit
+// clock is recovered from the tone transitions, and the NRZI-decoded bits
+// are passed to an HDLCDeframer.
+type AFSKDemodulator struct {
+	window int     // correlation window length, one bit period
+	step   float64 // bit clock phase increment per sample
+
+	// Reference oscillators and the sliding window of products
+	markStep, spaceStep float64
+	markPhase           float64
+	spacePhase          float64
+	hist                [][4]float64 // products of the samples in the window
+	pos                 int
+	markI, markQ        float64
+	spaceI, spaceQ      float64
+	samples             int // samples since the window sums were recalculated
+
+	bitPhase  float64 // bit clock phase, the bit is sampled when it wraps
+	lastTone  bool    // tone detected on the previous sample, true = mark
+	lastLevel bool    // tone of the previous sampled bit
+	deframer  HDLCDeframer
+}
+
+// NewAFSKDemodulator creates a demodulator for audio at the given sample
+// rate. The sample rate must be above 4400 Hz, and should be at least
+// 9600 Hz; 22050 Hz and higher give the best results.
+func NewAFSKDemodulator(sampleRate int) (*AFSKDemodulator, error) {
+	if sampleRate < afskMinSampleRate {
+		return nil, &ParseError{Code: ErrAFSKInvalid.Code, Msg: fmt.Sprintf("sample rate too low: %d Hz", sampleRate)}
+	}
+
+	window := int(math.Round(float64(sampleRate) / afskBaud))
+	return &AFSKDemodulator{
+		window:    window,
+		step:      afskBaud / float64(sampleRate),
+		markStep:  2 * math.Pi * afskMark / float64(sampleRate),
+		spaceStep: 2 * math.Pi * afskSpace / float64(sampleRate),
+		hist:      make([][4]float64, window),
+	}, nil
+}
+
+// Demodulate processes audio samples, which are in the range -1 to 1, and
+// returns the AX.25 frames, without FCS, completed by them. Frames can
+// span several calls.
+func (d *AFSKDemodulator) Demodulate(samples []float64) [][]byte {
+	var frames [][]byte
+	for _, s := range samples {
+		if frame := d.sample(s); frame != nil {
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
+
+// sample processes one audio sample.
+func (d *AFSKDemodulator) sample(s float64) []byte {
+	// Correlate with the mark and space tones over the last bit period.
+	// The magnitude of the correlation does not depend on the phase of
+	// the reference oscillators.
+	p := [4]float64{
+		s * math.Cos(d.markPhase), s * math.Sin(d.markPhase),
+		s * math.Cos(d.spacePhase), s * math.Sin(d.spacePhase),
+	}
+	old := d.hist[d.pos]
+	d.hist[d.pos] = p
+	d.pos = (d.pos + 1) % d.window
+
+	d.markI += p[0] - old[0]
+	d.markQ += p[1] - old[1]
+	d.spaceI += p[2] - old[2]
+	d.spaceQ += p[3] - old[3]
+
+	d.markPhase = math.Mod(d.markPhase+d.markStep, 2*math.Pi)
+	d.spacePhase = math.Mod(d.spacePhase+d.spaceStep, 2*math.Pi)
+
+	// Recalculate the running sums now and then to stop rounding errors
+	// from accumulating
+	d.samples++
+	if d.samples >= 100*d.window {
+		d.samples = 0
+		d.markI, d.markQ, d.spaceI, d.spaceQ = 0, 0, 0, 0
+		for _, h := range d.hist {
+			d.markI += h[0]
+			d.markQ += h[1]
+			d.spaceI += h[2]
+			d.spaceQ += h[3]
+		}
+	}
+
+	mark := math.Hypot(d.markI, d.markQ)
+	space := math.Hypot(d.spaceI, d.spaceQ)
+	tone := mark > space
+
+	// Bit clock recovery: tone transitions should happen half way between
+	// the sampling instants, when the phase is 0.5
+	if tone != d.lastTone {
+		d.bitPhase -= (d.bitPhase - 0.5) * afskPLLGain
+	}
+	d.lastTone = tone
+
+	d.bitPhase += d.step
+	if d.bitPhase < 1 {
+		return nil
+	}
+	d.bitPhase -= 1
+
+	// NRZI: no change of tone is a one bit, a change is a zero bit
+	bit := tone == d.lastLevel
+	d.lastLevel = tone
+
+	return d.deframer.Bit(bit)
+}
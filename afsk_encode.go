@@ -0,0 +1,119 @@
+package fap
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// AFSKModulatorOpts contains optional parameters for NewAFSKModulator.
+type AFSKModulatorOpts struct {
+	SampleRate int           // Sample rate in Hz, default 44100
+	TxDelay    time.Duration // Length of the flags sent before the frames, default 300 ms
+	TxTail     time.Duration // Length of the flags sent after the frames, default 20 ms, at least two flags
+	Amplitude  float64       // Peak amplitude 0-1, default 0.5
+}
+
+// AFSKModulator creates 1200 bit/s Bell 202 AFSK audio of AX.25 frames for
+// transmission. The audio can be demodulated with AFSKDemodulator.
+type AFSKModulator struct {
+	sampleRate int
+	preamble   int // number of flags before the frames
+	postamble  int // number of flags after the frames
+	amplitude  float64
+}
+
+// NewAFSKModulator creates a modulator. nil options give the defaults.
+func NewAFSKModulator(opts *AFSKModulatorOpts) (*AFSKModulator, error) {
+	if opts == nil {
+		opts = &AFSKModulatorOpts{}
+	}
+
+	m := &AFSKModulator{
+		sampleRate: opts.SampleRate,
+		amplitude:  opts.Amplitude,
+	}
+	if m.sampleRate == 0 {
+		m.sampleRate = 44100
+	}
+	if m.amplitude == 0 {
+		m.amplitude = 0.5
+	}
+	txDelay := opts.TxDelay
+	if txDelay == 0 {
+		txDelay = 300 * time.Millisecond
+	}
+	txTail := opts.TxTail
+	if txTail == 0 {
+		txTail = 20 * time.Millisecond
+	}
+
+	// A flag is 8 bits, rounded up to whole flags
+	flags := func(d time.Duration) int {
+		return max(1, int((d*afskBaud+8*time.Second-1)/(8*time.Second)))
+	}
+	m.preamble = flags(txDelay)
+	// The demodulator lags the audio, so a second flag is needed for the
+	// closing flag of the last frame to be received in full
+	m.postamble = max(2, flags(txTail))
+
+	if m.sampleRate < afskMinSampleRate {
+		return nil, &ParseError{Code: ErrAFSKEncInvalid.Code, Msg: fmt.Sprintf("sample rate too low: %d Hz", m.sampleRate)}
+	}
+	if m.amplitude < 0 || m.amplitude > 1 {
+		return nil, &ParseError{Code: ErrAFSKEncInvalid.Code, Msg: fmt.Sprintf("amplitude out of range: %f", m.amplitude)}
+	}
+	if txDelay < 0 || txTail < 0 {
+		return nil, &ParseError{Code: ErrAFSKEncInvalid.Code, Msg: "negative TX delay or tail"}
+	}
+
+	return m, nil
+}
+
+// SampleRate returns the sample rate of the audio created by the modulator.
+func (m *AFSKModulator) SampleRate() int {
+	return m.sampleRate
+}
+
+// Modulate creates the audio of a transmission of one or more AX.25
+// frames, without FCS, such as frames created by EncodeAX25. The frames
+// are sent back to back, separated by a flag, after the TX delay flags.
+func (m *AFSKModulator) Modulate(frames ...[]byte) []float64 {
+	var bits []bool
+	for i, frame := range frames {
+		preamble, postamble := 1, 0
+		if i == 0 {
+			preamble = m.preamble
+		}
+		if i == len(frames)-1 {
+			postamble = m.postamble
+		}
+		bits = append(bits, hdlcBits(frame, preamble, postamble)...)
+	}
+
+	samplesPerBit := float64(m.sampleRate) / afskBaud
+	samples := make([]float64, 0, int(float64(len(bits))*samplesPerBit)+1)
+
+	// NRZI: a zero bit changes the tone, a one bit keeps it. The phase
+	// is continuous across tone changes.
+	mark := true
+	phase := 0.0
+	end := 0.0
+	for _, bit := range bits {
+		if !bit {
+			mark = !mark
+		}
+		step := 2 * math.Pi * afskSpace / float64(m.sampleRate)
+		if mark {
+			step = 2 * math.Pi * afskMark / float64(m.sampleRate)
+		}
+
+		end += samplesPerBit
+		for float64(len(samples)) < end {
+			samples = append(samples, m.amplitude*math.Sin(phase))
+			phase = math.Mod(phase+step, 2*math.Pi)
+		}
+	}
+
+	return samples
+}
@@ -0,0 +1,144 @@
+package fap
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAFSKModulateRoundTrip(t *testing.T) {
+	msg, err := EncodeMessage(&Message{Destination: "OH2XYZ", Text: "Modulated", ID: "42"})
+	if err != nil {
+		t.Fatalf("EncodeMessage error: %v", err)
+	}
+	wx, err := EncodeWeatherPositionless(&Weather{Temp: new(21.0), Humidity: new(50)}, time.Date(2026, 5, 4, 12, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("EncodeWeatherPositionless error: %v", err)
+	}
+
+	var frames [][]byte
+	for _, raw := range []string{
+		"N0CALL-9>APZ001,WIDE1-1,WIDE2-1:" + msg,
+		"N0CALL-13>APZ001:" + wx,
+	} {
+		frame, err := EncodeAX25FromTNC2(raw, nil)
+		if err != nil {
+			t.Fatalf("EncodeAX25FromTNC2 error: %v", err)
+		}
+		frames = append(frames, frame)
+	}
+
+	for _, rate := range []int{11025, 22050, 44100, 48000} {
+		m, err := NewAFSKModulator(&AFSKModulatorOpts{SampleRate: rate})
+		if err != nil {
+			t.Fatalf("NewAFSKModulator error: %v", err)
+		}
+		// Both frames in one transmission
+		audio := m.Modulate(frames...)
+
+		d, err := NewAFSKDemodulator(rate)
+		if err != nil {
+			t.Fatalf("NewAFSKDemodulator error: %v", err)
+		}
+		got := d.Demodulate(audio)
+		if len(got) != 2 {
+			t.Fatalf("%d Hz: demodulated %d frames, want 2", rate, len(got))
+		}
+
+		p, err := DecodeAX25(got[0])
+		if err != nil {
+			t.Fatalf("%d Hz: DecodeAX25 error: %v", rate, err)
+		}
+		if p.Message == nil || p.Message.Text != "Modulated" || p.Message.ID != "42" {
+			t.Errorf("%d Hz: message = %+v", rate, p.Message)
+		}
+
+		p, err = DecodeAX25(got[1])
+		if err != nil {
+			t.Fatalf("%d Hz: DecodeAX25 error: %v", rate, err)
+		}
+		if p.Wx == nil || p.Wx.Humidity == nil || *p.Wx.Humidity != 50 {
+			t.Errorf("%d Hz: weather = %+v", rate, p.Wx)
+		}
+	}
+}
+
+func TestAFSKModulateTiming(t *testing.T) {
+	frame := ax25TestFrame("N0CALL", "APRS", nil, ">x")
+	m, err := NewAFSKModulator(&AFSKModulatorOpts{SampleRate: 12000, TxDelay: 100 * time.Millisecond, TxTail: 20 * time.Millisecond, Amplitude: 0.8})
+	if err != nil {
+		t.Fatalf("NewAFSKModulator error: %v", err)
+	}
+
+	// 100 ms is 15 flags and 20 ms is 3 flags, at 10 samples per bit
+	audio := m.Modulate(frame)
+	if want := (15*8 + len(hdlcBits(frame, 0, 0)) + 3*8) * 10; len(audio) != want {
+		t.Errorf("samples = %d, want %d", len(audio), want)
+	}
+
+	peak := 0.0
+	for _, s := range audio {
+		peak = max(peak, s)
+	}
+	if peak < 0.79 || peak > 0.8 {
+		t.Errorf("peak = %f, want 0.8", peak)
+	}
+}
+
+func TestAFSKModulatorInvalid(t *testing.T) {
+	tests := []AFSKModulatorOpts{
+		{SampleRate: 4000},
+		{Amplitude: 1.5},
+		{Amplitude: -0.5},
+		{TxDelay: -time.Second},
+	}
+	for _, opts := range tests {
+		if _, err := NewAFSKModulator(&opts); !errors.Is(err, ErrAFSKEncInvalid) {
+			t.Errorf("NewAFSKModulator(%+v) error = %v, want %v", opts, err, ErrAFSKEncInvalid)
+		}
+	}
+}
+
+func TestWriteWAV(t *testing.T) {
+	frame, err := EncodeAX25("N0CALL", "APZ001", nil, ">Written", nil)
+	if err != nil {
+		t.Fatalf("EncodeAX25 error: %v", err)
+	}
+	m, err := NewAFSKModulator(nil)
+	if err != nil {
+		t.Fatalf("NewAFSKModulator error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteWAV(&buf, m.Modulate(frame), m.SampleRate()); err != nil {
+		t.Fatalf("WriteWAV error: %v", err)
+	}
+
+	samples, rate, err := ReadWAV(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadWAV error: %v", err)
+	}
+	if rate != 44100 {
+		t.Errorf("sample rate = %d, want 44100", rate)
+	}
+	if len(samples) != len(m.Modulate(frame)) {
+		t.Errorf("samples = %d, want %d", len(samples), len(m.Modulate(frame)))
+	}
+
+	packets, err := DecodeWAV(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("DecodeWAV error: %v", err)
+	}
+	if len(packets) != 1 || packets[0].Status != "Written" {
+		t.Errorf("decoded %d packets, want 1 with status %q", len(packets), "Written")
+	}
+}
+
+func TestPCM16(t *testing.T) {
+	got := PCM16([]float64{0, 1, -1, 2, 0.5})
+	want := []byte{0x00, 0x00, 0xFF, 0x7F, 0x01, 0x80, 0xFF, 0x7F, 0x00, 0x40}
+	if !bytes.Equal(got, want) {
+		t.Errorf("PCM16 = % X, want % X", got, want)
+	}
+}
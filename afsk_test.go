@@ -0,0 +1,252 @@
+package fap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// afskTestModulate generates audio of a frame at the given sample rate.
+func afskTestModulate(t *testing.T, frame []byte, sampleRate int) []float64 {
+	t.Helper()
+	m, err := NewAFSKModulator(&AFSKModulatorOpts{SampleRate: sampleRate, TxDelay: 150 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewAFSKModulator error: %v", err)
+	}
+	return m.Modulate(frame)
+}
+
+func TestFCS16(t *testing.T) {
+	// CRC-16/X-25 check value
+	if got := fcs16([]byte("123456789")); got != 0x906E {
+		t.Errorf("fcs16 = %04X, want 906E", got)
+	}
+}
+
+func TestHDLCDeframer(t *testing.T) {
+	frame := ax25TestFrame("N0CALL", "APRS", nil, ">\x7E\xFF\x1F stuffing")
+
+	var d HDLCDeframer
+	var got [][]byte
+	bits := hdlcBits(frame, 4, 3)
+	// Junk before the frame
+	bits = append([]bool{true, false, true, true, true, true, true, true, true, false}, bits...)
+	for _, b := range bits {
+		if f := d.Bit(b); f != nil {
+			got = append(got, f)
+		}
+	}
+	if len(got) != 1 || !bytes.Equal(got[0], frame) {
+		t.Fatalf("deframed %q, want %q", got, frame)
+	}
+
+	// A corrupted bit fails the FCS check
+	bits = hdlcBits(frame, 4, 3)
+	bits[4*8+20] = !bits[4*8+20]
+	for _, b := range bits {
+		if f := d.Bit(b); f != nil {
+			t.Errorf("deframed corrupted frame %q", f)
+		}
+	}
+
+	// An abort discards the frame
+	bits = hdlcBits(frame, 4, 3)
+	abort := []bool{true, true, true, true, true, true, true, true}
+	bits = append(append(bits[:4*8+40:4*8+40], abort...), bits[4*8+40:]...)
+	for _, b := range bits {
+		if f := d.Bit(b); f != nil {
+			t.Errorf("deframed aborted frame %q", f)
+		}
+	}
+}
+
+func TestAFSKDemodulate(t *testing.T) {
+	pos, err := EncodePosition(60.4752, 25.0947, new(90.0), new(50.0), nil, "/>", &EncodePositionOpts{Comment: "AFSK test"})
+	if err != nil {
+		t.Fatalf("EncodePosition error: %v", err)
+	}
+	frame := ax25TestFrame("OH2XYZ-9", "APZ001", []string{"WIDE1*", "WIDE2-1"}, pos)
+
+	for _, rate := range []int{9600, 11025, 22050, 44100, 48000} {
+		samples := afskTestModulate(t, frame, rate)
+
+		// Two frames with silence and noise around them
+		rng := rand.New(rand.NewPCG(1, 2))
+		var audio []float64
+		noise := func(n int) {
+			for range n {
+				audio = append(audio, 0.05*(rng.Float64()*2-1))
+			}
+		}
+		noise(rate / 10)
+		audio = append(audio, samples...)
+		noise(rate / 10)
+		audio = append(audio, samples...)
+		noise(rate / 10)
+		for i := range audio {
+			audio[i] += 0.1 * (rng.Float64()*2 - 1)
+		}
+
+		// Feed the audio in chunks, frames span calls
+		d, err := NewAFSKDemodulator(rate)
+		if err != nil {
+			t.Fatalf("NewAFSKDemodulator error: %v", err)
+		}
+		var frames [][]byte
+		for i := 0; i < len(audio); i += 1000 {
+			frames = append(frames, d.Demodulate(audio[i:min(i+1000, len(audio))])...)
+		}
+
+		if len(frames) != 2 {
+			t.Errorf("%d Hz: demodulated %d frames, want 2", rate, len(frames))
+			continue
+		}
+		for _, f := range frames {
+			p, err := DecodeAX25(f)
+			if err != nil {
+				t.Errorf("%d Hz: DecodeAX25 error: %v", rate, err)
+				continue
+			}
+			if p.Header != "OH2XYZ-9>APZ001,WIDE1*,WIDE2-1" || p.Comment != "AFSK test" {
+				t.Errorf("%d Hz: packet %q %q", rate, p.Header, p.Comment)
+			}
+		}
+	}
+}
+
+// wavTestFile creates a 16-bit PCM WAV file.
+func wavTestFile(samples []float64, sampleRate, channels int) []byte {
+	var data bytes.Buffer
+	for _, s := range samples {
+		for range channels {
+			binary.Write(&data, binary.LittleEndian, int16(s*32767))
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+8+16+8+8+data.Len()))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(1))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate*channels*2))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels*2))
+	binary.Write(&buf, binary.LittleEndian, uint16(16))
+	buf.WriteString("LIST")
+	binary.Write(&buf, binary.LittleEndian, uint32(3))
+	buf.WriteString("abc\x00")
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(data.Len()))
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+func TestDecodeWAV(t *testing.T) {
+	frame := ax25TestFrame("N0CALL", "APRS", nil, ">WAV test")
+	samples := afskTestModulate(t, frame, 22050)
+	samples = append(samples, make([]float64, 1000)...)
+
+	packets, err := DecodeWAV(bytes.NewReader(wavTestFile(samples, 22050, 2)))
+	if err != nil {
+		t.Fatalf("DecodeWAV error: %v", err)
+	}
+	if len(packets) != 1 {
+		t.Fatalf("decoded %d packets, want 1", len(packets))
+	}
+	if packets[0].Status != "WAV test" {
+		t.Errorf("status = %q, want %q", packets[0].Status, "WAV test")
+	}
+}
+
+func TestDecodeWAVParseErrors(t *testing.T) {
+	good := ax25TestFrame("N0CALL", "APRS", nil, ">good")
+	bad := ax25TestFrame("N0CALL", "APRS", nil, ">bad")
+	bad[14] = 0x00 // I frame, not UI
+
+	m, err := NewAFSKModulator(&AFSKModulatorOpts{SampleRate: 22050, TxDelay: 150 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewAFSKModulator error: %v", err)
+	}
+	samples := append(m.Modulate(bad, good, bad), make([]float64, 1000)...)
+
+	packets, err := DecodeWAV(bytes.NewReader(wavTestFile(samples, 22050, 1)))
+	if !errors.Is(err, ErrAX25NotUI) {
+		t.Fatalf("DecodeWAV error = %v, want %v", err, ErrAX25NotUI)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 2 {
+		t.Errorf("DecodeWAV returned %d errors, want 2", n)
+	}
+	if len(packets) != 1 || packets[0].Status != "good" {
+		t.Errorf("packets = %v, want the good packet", packets)
+	}
+}
+
+func TestReadWAVInvalid(t *testing.T) {
+	valid := wavTestFile([]float64{0, 0.5}, 8000, 1)
+
+	float := append([]byte(nil), valid...)
+	float[20] = 3 // IEEE float
+
+	bits24 := append([]byte(nil), valid...)
+	bits24[34] = 24
+
+	tests := map[string][]byte{
+		"empty":    nil,
+		"not RIFF": []byte("RIFX\x00\x00\x00\x00WAVEfmt "),
+		"no data":  valid[:36],
+		"float":    float,
+		"24 bits":  bits24,
+	}
+	for name, data := range tests {
+		if _, _, err := ReadWAV(bytes.NewReader(data)); !errors.Is(err, ErrWAVInvalid) {
+			t.Errorf("%s: error = %v, want %v", name, err, ErrWAVInvalid)
+		}
+	}
+
+	samples, rate, err := ReadWAV(bytes.NewReader(valid))
+	if err != nil {
+		t.Fatalf("ReadWAV error: %v", err)
+	}
+	if rate != 8000 || len(samples) != 2 || math.Abs(samples[1]-0.5) > 0.001 {
+		t.Errorf("ReadWAV = %v %d, want [0 0.5] 8000", samples, rate)
+	}
+}
+
+func TestAFSKDemodulatorSampleRate(t *testing.T) {
+	for _, rate := range []int{0, 1, 100, 599, 4400} {
+		if _, err := NewAFSKDemodulator(rate); !errors.Is(err, ErrAFSKInvalid) {
+			t.Errorf("NewAFSKDemodulator(%d) error = %v, want %v", rate, err, ErrAFSKInvalid)
+		}
+	}
+
+	// A WAV file with a valid header but a sample rate too low for AFSK
+	var buf bytes.Buffer
+	if err := WriteWAV(&buf, make([]float64, 100), 100); err != nil {
+		t.Fatalf("WriteWAV error: %v", err)
+	}
+	if _, err := DecodeWAV(&buf); !errors.Is(err, ErrAFSKInvalid) {
+		t.Errorf("DecodeWAV error = %v, want %v", err, ErrAFSKInvalid)
+	}
+}
+
+func TestAFSKDemodulateClockOffset(t *testing.T) {
+	// A transmitter with a 2% clock error
+	frame := ax25TestFrame("N0CALL", "APRS", nil, ">A longer status text to give the bit clock time to drift")
+	samples := afskTestModulate(t, frame, 22500)
+
+	d, err := NewAFSKDemodulator(22050)
+	if err != nil {
+		t.Fatalf("NewAFSKDemodulator error: %v", err)
+	}
+	frames := d.Demodulate(samples)
+	if len(frames) != 1 || !bytes.Equal(frames[0], frame) {
+		t.Errorf("demodulated %q, want %q", frames, frame)
+	}
+}
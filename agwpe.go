@@ -0,0 +1,350 @@
+package fap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AGWPE data kinds used by AGWPEConn.
+const (
+	AGWPEKindRegister   = 'X' // Register a callsign, answered with 1 (success) or 0
+	AGWPEKindMonitor    = 'm' // Toggle reception of monitored frames
+	AGWPEKindRaw        = 'k' // Toggle reception of raw AX.25 frames
+	AGWPEKindMonitorUI  = 'U' // Monitored UI frame, as text
+	AGWPEKindRawFrame   = 'K' // Raw AX.25 frame, preceded by a KISS type byte
+	AGWPEKindUnproto    = 'M' // Send a UI frame without a via path
+	AGWPEKindUnprotoVia = 'V' // Send a UI frame with a via path
+	AGWPEKindVersion    = 'R' // Software version
+	AGWPEKindPortInfo   = 'G' // Port information
+)
+
+const (
+	agwpeHeaderLen   = 36   // Length of the frame header
+	agwpeCallLen     = 10   // Length of a callsign field
+	agwpeMaxData     = 4096 // Largest data field accepted
+	agwpeLoginWait   = 5 * time.Second
+	agwpeDialTimeout = 10 * time.Second
+)
+
+// AGWPEFrame is a frame of the AGWPE TCP API. The header carries the
+// radio port, the data kind, the protocol identifier and two callsigns.
+type AGWPEFrame struct {
+	Port     int    // Radio port, 0 = first port
+	Kind     byte   // Data kind, e.g. AGWPEKindMonitorUI
+	PID      byte   // AX.25 protocol identifier
+	CallFrom string // Source callsign
+	CallTo   string // Destination callsign
+	Data     []byte // Frame data
+}
+
+// encode creates the binary form of the frame.
+func (f *AGWPEFrame) encode() ([]byte, error) {
+	if f.Port < 0 || f.Port > 255 {
+		return nil, &ParseError{Code: ErrAGWPEInvalid.Code, Msg: fmt.Sprintf("port out of range: %d", f.Port)}
+	}
+	if len(f.CallFrom) >= agwpeCallLen || len(f.CallTo) >= agwpeCallLen {
+		return nil, &ParseError{Code: ErrAGWPEInvalid.Code, Msg: "callsign too long"}
+	}
+	if len(f.Data) > agwpeMaxData {
+		return nil, &ParseError{Code: ErrAGWPEInvalid.Code, Msg: fmt.Sprintf("data too long: %d bytes", len(f.Data))}
+	}
+
+	buf := make([]byte, agwpeHeaderLen, agwpeHeaderLen+len(f.Data))
+	buf[0] = byte(f.Port)
+	buf[4] = f.Kind
+	buf[6] = f.PID
+	copy(buf[8:8+agwpeCallLen], f.CallFrom)
+	copy(buf[18:18+agwpeCallLen], f.CallTo)
+	binary.LittleEndian.PutUint32(buf[28:32], uint32(len(f.Data)))
+
+	return append(buf, f.Data...), nil
+}
+
+// decodeAGWPEHeader decodes a frame header, returning the frame without
+// data and the length of the data.
+func decodeAGWPEHeader(hdr []byte) (*AGWPEFrame, int, error) {
+	n := binary.LittleEndian.Uint32(hdr[28:32])
+	if n > agwpeMaxData {
+		return nil, 0, &ParseError{Code: ErrAGWPEInvalid.Code, Msg: fmt.Sprintf("data too long: %d bytes", n)}
+	}
+
+	f := &AGWPEFrame{
+		Port:     int(hdr[0]),
+		Kind:     hdr[4],
+		PID:      hdr[6],
+		CallFrom: agwpeCall(hdr[8 : 8+agwpeCallLen]),
+		CallTo:   agwpeCall(hdr[18 : 18+agwpeCallLen]),
+	}
+	return f, int(n), nil
+}
+
+// agwpeCall returns the callsign in a null-padded callsign field.
+func agwpeCall(field []byte) string {
+	if i := strings.IndexByte(string(field), 0); i >= 0 {
+		field = field[:i]
+	}
+	return strings.TrimSpace(string(field))
+}
+
+// AGWPEConn is a connection to a packet engine speaking the AGWPE TCP API,
+// such as AGWPE, Direwolf or soundmodem. It can be read and written
+// concurrently from different goroutines.
+type AGWPEConn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	callsign string
+	opt      options // parsing options for ReadPacket
+
+	wmu sync.Mutex // serializes writes
+}
+
+// DialAGWPE connects to an AGWPE server at addr (host:port), registers the
+// callsign and enables reception of raw AX.25 frames, which carry the
+// received frames as they are. The parsing options are used to decode the
+// packets returned by ReadPacket.
+func DialAGWPE(addr, callsign string, opts ...Option) (*AGWPEConn, error) {
+	if CheckAX25Call(callsign) == "" {
+		return nil, &ParseError{Code: ErrAGWPEInvalid.Code, Msg: fmt.Sprintf("callsign is not a valid AX.25 call: %q", callsign)}
+	}
+
+	tc, err := net.DialTimeout("tcp", addr, agwpeDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &AGWPEConn{
+		conn:     tc,
+		reader:   bufio.NewReaderSize(tc, agwpeHeaderLen+agwpeMaxData),
+		callsign: CheckAX25Call(callsign),
+	}
+	for _, o := range opts {
+		o(&c.opt)
+	}
+
+	if err := c.WriteFrame(&AGWPEFrame{Kind: AGWPEKindRegister, CallFrom: c.callsign}); err != nil {
+		tc.Close()
+		return nil, fmt.Errorf("failed to send registration: %w", err)
+	}
+
+	// Wait for the registration reply, skipping other frames.
+	deadline := time.Now().Add(agwpeLoginWait)
+	for {
+		f, err := c.ReadFrame(time.Until(deadline))
+		if err != nil {
+			tc.Close()
+			return nil, fmt.Errorf("failed to read registration reply: %w", err)
+		}
+		if f.Kind != AGWPEKindRegister {
+			continue
+		}
+		if len(f.Data) < 1 || f.Data[0] != 1 {
+			tc.Close()
+			return nil, fmt.Errorf("callsign %s registration rejected", c.callsign)
+		}
+		break
+	}
+
+	// Monitoring ('m') is not enabled as well, since the server would then
+	// send each received frame twice
+	if err := c.WriteFrame(&AGWPEFrame{Kind: AGWPEKindRaw}); err != nil {
+		tc.Close()
+		return nil, fmt.Errorf("failed to enable raw frames: %w", err)
+	}
+
+	return c, nil
+}
+
+// ReadFrame reads the next frame from the server. The provided timeout
+// sets a read deadline. A frame which has not been completely received
+// when the timeout expires is kept for the next call.
+func (c *AGWPEConn) ReadFrame(timeout time.Duration) (*AGWPEFrame, error) {
+	if err := c.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	// Peek does not consume data on failure, so a timeout does not lose
+	// the position in the stream
+	hdr, err := c.reader.Peek(agwpeHeaderLen)
+	if err != nil {
+		return nil, err
+	}
+	f, n, err := decodeAGWPEHeader(hdr)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := c.reader.Peek(agwpeHeaderLen + n)
+	if err != nil {
+		return nil, err
+	}
+	f.Data = append([]byte(nil), buf[agwpeHeaderLen:]...)
+	if _, err := c.reader.Discard(agwpeHeaderLen + n); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// ReadPacket reads frames until a raw AX.25 frame ('K') or a monitored UI
+// frame ('U') is received, and returns it parsed, along with the radio
+// port it was received on. Monitored frames are only sent by the server
+// if monitoring has been enabled with WriteFrame. Other frames are
+// skipped. If the frame cannot be decoded, the packet is returned with the
+// error, like Parse does. The timeout applies to each individual read.
+func (c *AGWPEConn) ReadPacket(timeout time.Duration) (*Packet, int, error) {
+	for {
+		f, err := c.ReadFrame(timeout)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		switch f.Kind {
+		case AGWPEKindMonitorUI:
+			src, dst, digis, info, err := decodeAGWPEMonitor(f.Data)
+			if err != nil {
+				return &Packet{}, f.Port, err
+			}
+			p, err := parseUIFrame(src, dst, digis, info, &c.opt)
+			return p, f.Port, err
+
+		case AGWPEKindRawFrame:
+			// The frame is preceded by a KISS type byte
+			if len(f.Data) < 1 {
+				return &Packet{}, f.Port, &ParseError{Code: ErrAGWPEInvalid.Code, Msg: "empty raw frame"}
+			}
+			src, dst, digis, info, err := decodeAX25Frame(f.Data[1:])
+			if err != nil {
+				return &Packet{}, f.Port, err
+			}
+			p, err := parseUIFrame(src, dst, digis, info, &c.opt)
+			return p, f.Port, err
+		}
+	}
+}
+
+// decodeAGWPEMonitor decodes the text of a monitored UI frame:
+//
+//	1:Fm SRC To DST Via DIGI1,DIGI2* <UI pid=F0 Len=12 >[12:34:56]\r<info>\r
+//
+// Repeated digipeaters are marked with '*'.
+func decodeAGWPEMonitor(data []byte) (src, dst string, digis []Digipeater, info string, err error) {
+	text := string(data)
+	header, info, ok := strings.Cut(text, "\r")
+	if !ok {
+		return "", "", nil, "", &ParseError{Code: ErrAGWPEInvalid.Code, Msg: "no end of header in monitored frame"}
+	}
+	info = strings.TrimRight(info, "\r\n\x00")
+
+	i := strings.Index(header, "Fm ")
+	if i < 0 {
+		return "", "", nil, "", &ParseError{Code: ErrAGWPEInvalid.Code, Msg: "no source in monitored frame"}
+	}
+	fields := strings.Fields(header[i:])
+
+	isUI := false
+	for j := 0; j < len(fields); j++ {
+		switch {
+		case fields[j] == "Fm" && j+1 < len(fields):
+			j++
+			src = fields[j]
+		case fields[j] == "To" && j+1 < len(fields):
+			j++
+			dst = fields[j]
+		case fields[j] == "Via" && j+1 < len(fields):
+			j++
+			for _, d := range strings.Split(fields[j], ",") {
+				call, used := strings.CutSuffix(d, "*")
+				digis = append(digis, Digipeater{Call: call, WasDigied: used})
+			}
+		case fields[j] == "<UI":
+			isUI = true
+		case strings.HasPrefix(fields[j], "pid="):
+			if !strings.EqualFold(fields[j][4:], "F0") {
+				return "", "", nil, "", &ParseError{Code: ErrAX25NotUI.Code, Msg: fmt.Sprintf("unsupported protocol identifier %s", fields[j][4:])}
+			}
+		}
+	}
+	if !isUI {
+		return "", "", nil, "", &ParseError{Code: ErrAX25NotUI.Code, Msg: "not a UI frame"}
+	}
+	if src == "" || dst == "" {
+		return "", "", nil, "", &ParseError{Code: ErrAGWPEInvalid.Code, Msg: "no source or destination in monitored frame"}
+	}
+
+	return src, dst, digis, info, nil
+}
+
+// WriteFrame sends a frame to the server.
+func (c *AGWPEConn) WriteFrame(f *AGWPEFrame) error {
+	data, err := f.encode()
+	if err != nil {
+		return err
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	_, err = c.conn.Write(data)
+	return err
+}
+
+// SendPacket transmits a packet in TNC2 format (SRC>DST,PATH:body) on a
+// radio port, as an unproto frame ('M', or 'V' with a via path). The
+// callsigns are validated with CheckAX25Call. AGWPE does not allow setting
+// the has-been-repeated bits, so '*' marks in the path are ignored.
+func (c *AGWPEConn) SendPacket(port int, packet string) error {
+	src, dst, digis, body, err := splitTNC2(packet)
+	if err != nil {
+		return err
+	}
+	if body == "" {
+		return &ParseError{Code: ErrAX25EncInvalid.Code, Msg: "empty packet body"}
+	}
+	if len(digis) > ax25MaxDigis {
+		return &ParseError{Code: ErrAX25EncInvalid.Code, Msg: fmt.Sprintf("too many digipeaters: %d", len(digis))}
+	}
+
+	f := &AGWPEFrame{
+		Port:     port,
+		Kind:     AGWPEKindUnproto,
+		PID:      ax25PIDNoLayer,
+		CallFrom: CheckAX25Call(src),
+		CallTo:   CheckAX25Call(dst),
+	}
+	if f.CallFrom == "" {
+		return &ParseError{Code: ErrAX25EncInvalid.Code, Msg: fmt.Sprintf("source callsign is not a valid AX.25 call: %q", src)}
+	}
+	if f.CallTo == "" {
+		return &ParseError{Code: ErrAX25EncInvalid.Code, Msg: fmt.Sprintf("destination callsign is not a valid AX.25 call: %q", dst)}
+	}
+
+	if len(digis) == 0 {
+		f.Data = []byte(body)
+		return c.WriteFrame(f)
+	}
+
+	// The via path is a count followed by null-padded callsign fields
+	f.Kind = AGWPEKindUnprotoVia
+	f.Data = append(f.Data, byte(len(digis)))
+	for _, d := range digis {
+		call := CheckAX25Call(d.Call)
+		if call == "" {
+			return &ParseError{Code: ErrAX25EncInvalid.Code, Msg: fmt.Sprintf("digipeater callsign is not a valid AX.25 call: %q", d.Call)}
+		}
+		field := make([]byte, agwpeCallLen)
+		copy(field, call)
+		f.Data = append(f.Data, field...)
+	}
+	f.Data = append(f.Data, body...)
+
+	return c.WriteFrame(f)
+}
+
+// Close closes the underlying TCP connection.
+func (c *AGWPEConn) Close() error {
+	return c.conn.Close()
+}
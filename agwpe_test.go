@@ -0,0 +1,258 @@
+package fap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// agwpeTestServer is a stand-in AGWPE server. It answers the callsign
+// registration, records the frames it receives and sends the given frames
+// once raw frames have been enabled.
+type agwpeTestServer struct {
+	addr     string
+	accept   bool             // accept the callsign registration
+	send     []*AGWPEFrame    // frames sent after raw frames are enabled
+	received chan *AGWPEFrame // frames received after registration
+}
+
+func newAGWPETestServer(t *testing.T, accept bool, send ...*AGWPEFrame) *agwpeTestServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &agwpeTestServer{
+		addr:     ln.Addr().String(),
+		accept:   accept,
+		send:     send,
+		received: make(chan *AGWPEFrame, 10),
+	}
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(conn)
+	}()
+
+	return s
+}
+
+func (s *agwpeTestServer) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	for {
+		hdr := make([]byte, agwpeHeaderLen)
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return
+		}
+		f, n, err := decodeAGWPEHeader(hdr)
+		if err != nil {
+			return
+		}
+		f.Data = make([]byte, n)
+		if _, err := io.ReadFull(r, f.Data); err != nil {
+			return
+		}
+
+		switch f.Kind {
+		case AGWPEKindRegister:
+			// Some other frame first, which the client must skip
+			version, _ := (&AGWPEFrame{Kind: AGWPEKindVersion, Data: make([]byte, 8)}).encode()
+			conn.Write(version)
+
+			result := byte(0)
+			if s.accept {
+				result = 1
+			}
+			reply, _ := (&AGWPEFrame{Kind: AGWPEKindRegister, CallFrom: f.CallFrom, Data: []byte{result}}).encode()
+			conn.Write(reply)
+		case AGWPEKindRaw:
+			for _, sf := range s.send {
+				data, _ := sf.encode()
+				conn.Write(data)
+			}
+		default:
+			s.received <- f
+		}
+	}
+}
+
+func TestAGWPEMonitor(t *testing.T) {
+	raw := ax25TestFrame("N0CALL-9", "APDR16", []string{"WIDE1*", "WIDE2-1"}, ">raw frame")
+	s := newAGWPETestServer(t, true,
+		&AGWPEFrame{Port: 0, Kind: AGWPEKindMonitorUI, PID: 0xF0, CallFrom: "OH2RDP-1", CallTo: "BEACON-15",
+			Data: []byte(" 1:Fm OH2RDP-1 To BEACON-15 Via OH2RDG*,WIDE <UI pid=F0 Len=35 >[12:34:56]\r" +
+				"!6028.51N/02505.68E#PHG7220/RELAY\r\n\x00")},
+		&AGWPEFrame{Port: 1, Kind: 'I', Data: []byte(" 2:Fm N0CALL To N1CALL <I R0 S0 pid=F0 Len=3 >\rabc\r")},
+		&AGWPEFrame{Port: 1, Kind: AGWPEKindRawFrame, Data: append([]byte{0x10}, raw...)},
+	)
+
+	c, err := DialAGWPE(s.addr, "N0CALL", WithDeviceID())
+	if err != nil {
+		t.Fatalf("DialAGWPE failed: %v", err)
+	}
+	defer c.Close()
+
+	// Monitored UI frame
+	p, port, err := c.ReadPacket(2 * time.Second)
+	if err != nil {
+		t.Fatalf("ReadPacket failed: %v", err)
+	}
+	if port != 0 {
+		t.Errorf("port = %d, want 0", port)
+	}
+	if p.Header != "OH2RDP-1>BEACON-15,OH2RDG*,WIDE" {
+		t.Errorf("header = %q, want %q", p.Header, "OH2RDP-1>BEACON-15,OH2RDG*,WIDE")
+	}
+	if p.Comment != "RELAY" {
+		t.Errorf("comment = %q, want %q", p.Comment, "RELAY")
+	}
+	if len(p.Digipeaters) != 2 || !p.Digipeaters[0].WasDigied || p.Digipeaters[1].WasDigied {
+		t.Errorf("digipeaters = %+v, want OH2RDG digied, WIDE not", p.Digipeaters)
+	}
+
+	// The I frame is skipped, raw frame follows
+	p, port, err = c.ReadPacket(2 * time.Second)
+	if err != nil {
+		t.Fatalf("ReadPacket failed: %v", err)
+	}
+	if port != 1 {
+		t.Errorf("port = %d, want 1", port)
+	}
+	if p.Header != "N0CALL-9>APDR16,WIDE1*,WIDE2-1" || p.Status != "raw frame" {
+		t.Errorf("packet = %q %q, want %q %q", p.Header, p.Status, "N0CALL-9>APDR16,WIDE1*,WIDE2-1", "raw frame")
+	}
+	if p.Device == nil || p.Device.Model != "APRSdroid" {
+		t.Errorf("device = %+v, want APRSdroid", p.Device)
+	}
+
+	// No more frames: the read times out
+	_, _, err = c.ReadPacket(50 * time.Millisecond)
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("ReadPacket error = %v, want timeout", err)
+	}
+}
+
+func TestAGWPESend(t *testing.T) {
+	s := newAGWPETestServer(t, true)
+
+	c, err := DialAGWPE(s.addr, "N0CALL")
+	if err != nil {
+		t.Fatalf("DialAGWPE failed: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.SendPacket(0, "N0CALL>APRS:>no path"); err != nil {
+		t.Fatalf("SendPacket failed: %v", err)
+	}
+	if err := c.SendPacket(2, "n0call-9>APRS,WIDE1-1,WIDE2-2:>via path"); err != nil {
+		t.Fatalf("SendPacket failed: %v", err)
+	}
+
+	f := agwpeTestReceive(t, s)
+	if f.Kind != AGWPEKindUnproto || f.Port != 0 || f.PID != 0xF0 || f.CallFrom != "N0CALL" || f.CallTo != "APRS" {
+		t.Errorf("frame = %c port %d pid %02X %s>%s, want M port 0 pid F0 N0CALL>APRS", f.Kind, f.Port, f.PID, f.CallFrom, f.CallTo)
+	}
+	if string(f.Data) != ">no path" {
+		t.Errorf("data = %q, want %q", f.Data, ">no path")
+	}
+
+	f = agwpeTestReceive(t, s)
+	if f.Kind != AGWPEKindUnprotoVia || f.Port != 2 || f.CallFrom != "N0CALL-9" || f.CallTo != "APRS" {
+		t.Errorf("frame = %c port %d %s>%s, want V port 2 N0CALL-9>APRS", f.Kind, f.Port, f.CallFrom, f.CallTo)
+	}
+	want := "\x02WIDE1-1\x00\x00\x00WIDE2-2\x00\x00\x00>via path"
+	if string(f.Data) != want {
+		t.Errorf("data = %q, want %q", f.Data, want)
+	}
+
+	for _, packet := range []string{"N0CALL>APRS", "N0CALL>APRS,qAR,T2FINLAND:>x", "N0CALL>APRS:"} {
+		if err := c.SendPacket(0, packet); !errors.Is(err, ErrAX25EncInvalid) {
+			t.Errorf("SendPacket(%q) error = %v, want %v", packet, err, ErrAX25EncInvalid)
+		}
+	}
+}
+
+func agwpeTestReceive(t *testing.T, s *agwpeTestServer) *AGWPEFrame {
+	t.Helper()
+	select {
+	case f := <-s.received:
+		return f
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for frame")
+	}
+	return nil
+}
+
+func TestAGWPERegisterRejected(t *testing.T) {
+	s := newAGWPETestServer(t, false)
+	_, err := DialAGWPE(s.addr, "N0CALL")
+	if err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("DialAGWPE error = %v, want registration rejected", err)
+	}
+
+	if _, err := DialAGWPE(s.addr, "BAD CALL"); !errors.Is(err, ErrAGWPEInvalid) {
+		t.Errorf("DialAGWPE error = %v, want %v", err, ErrAGWPEInvalid)
+	}
+}
+
+func TestAGWPEFrameEncode(t *testing.T) {
+	f := &AGWPEFrame{Port: 1, Kind: AGWPEKindUnproto, PID: 0xF0, CallFrom: "N0CALL-9", CallTo: "APRS", Data: []byte("abc")}
+	data, err := f.encode()
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	if len(data) != agwpeHeaderLen+3 {
+		t.Fatalf("length = %d, want %d", len(data), agwpeHeaderLen+3)
+	}
+	if data[0] != 1 || data[4] != 'M' || data[6] != 0xF0 || binary.LittleEndian.Uint32(data[28:32]) != 3 {
+		t.Errorf("header = % X", data[:agwpeHeaderLen])
+	}
+	if string(data[8:18]) != "N0CALL-9\x00\x00" || string(data[18:28]) != "APRS\x00\x00\x00\x00\x00\x00" {
+		t.Errorf("callsigns = %q %q", data[8:18], data[18:28])
+	}
+
+	for _, bad := range []*AGWPEFrame{
+		{Port: 256},
+		{CallFrom: "TOOLONGCALL"},
+		{Data: make([]byte, agwpeMaxData+1)},
+	} {
+		if _, err := bad.encode(); !errors.Is(err, ErrAGWPEInvalid) {
+			t.Errorf("encode(%+v) error = %v, want %v", bad.Port, err, ErrAGWPEInvalid)
+		}
+	}
+}
+
+func TestAGWPEMonitorDecode(t *testing.T) {
+	tests := []struct {
+		text string
+		want *ParseError
+	}{
+		{" 1:Fm N0CALL To APRS <UI pid=F0 Len=3 >[12:00:00]\r>hi\r", nil},
+		{" 1:Fm N0CALL To APRS <UI pid=CF Len=3 >[12:00:00]\r>hi\r", ErrAX25NotUI},
+		{" 1:Fm N0CALL To APRS <I R0 S0 pid=F0 Len=3 >[12:00:00]\r>hi\r", ErrAX25NotUI},
+		{" 1:Fm N0CALL <UI pid=F0 Len=3 >[12:00:00]\r>hi\r", ErrAGWPEInvalid},
+		{" 1:Fm N0CALL To APRS <UI pid=F0 Len=3 >", ErrAGWPEInvalid},
+	}
+	for _, tt := range tests {
+		_, _, _, _, err := decodeAGWPEMonitor([]byte(tt.text))
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("decodeAGWPEMonitor(%q) error = %v", tt.text, err)
+			}
+		} else if !errors.Is(err, tt.want) {
+			t.Errorf("decodeAGWPEMonitor(%q) error = %v, want %v", tt.text, err, tt.want)
+		}
+	}
+}
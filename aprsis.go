@@ -2,16 +2,36 @@ package fap
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"strings"
 	"time"
 )
 
+// ErrLoginRejected is returned when the server rejects the login, by
+// closing the connection or with an invalid login response. The error
+// returned by Dial wraps it with the server's reason.
+var ErrLoginRejected = errors.New("APRS-IS login rejected")
+
+// LoginResponse contains the details of a login, from the server's
+// banner and its "# logresp" reply.
+type LoginResponse struct {
+	Callsign string // Callsign confirmed by the server
+	Verified bool   // The passcode was accepted; unverified logins cannot transmit
+	Server   string // Server ID, such as "T2FINLAND"
+	Software string // Server software and version, such as "aprsc 2.1.19-g730c5c0"
+}
+
 // Conn represents a connection to an APRS-IS server.
 type Conn struct {
+	Login LoginResponse // Details of the login
+
 	conn     net.Conn
 	reader   *bufio.Reader
+	partial  string    // start of a line interrupted by a read timeout
+	rxTime   time.Time // time data was last received from the server
 	callsign string
 	passcode string
 	appName  string
@@ -19,6 +39,9 @@ type Conn struct {
 	filter   string
 }
 
+// aprsisLoginTimeout is the time to wait for the server's login response.
+const aprsisLoginTimeout = 5 * time.Second
+
 // Dial connects to an APRS-IS server, sends the login line, and waits
 // for a "# logresp" reply. An optional filter string can be provided.
 func Dial(addr, callsign, passcode, appName, appVer string, filter ...string) (*Conn, error) {
@@ -27,49 +50,115 @@ func Dial(addr, callsign, passcode, appName, appVer string, filter ...string) (*
 		return nil, err
 	}
 
+	c := newConn(tc, callsign, passcode, appName, appVer)
+	if len(filter) > 0 {
+		c.filter = filter[0]
+	}
+
+	if err := c.login(); err != nil {
+		tc.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// newConn wraps a TCP connection to a server, before login.
+func newConn(tc net.Conn, callsign, passcode, appName, appVer string) *Conn {
 	c := &Conn{
 		conn:     tc,
-		reader:   bufio.NewReader(tc),
 		callsign: callsign,
 		passcode: passcode,
 		appName:  appName,
 		appVer:   appVer,
 	}
+	c.reader = bufio.NewReader(rxTimeReader{r: tc, rxTime: &c.rxTime})
+	return c
+}
 
-	if len(filter) > 0 {
-		c.filter = filter[0]
+// rxTimeReader records the time data was last received from a reader.
+type rxTimeReader struct {
+	r      io.Reader
+	rxTime *time.Time
+}
+
+func (r rxTimeReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		*r.rxTime = time.Now()
 	}
+	return n, err
+}
 
+// login sends the login line and waits for the "# logresp" reply.
+func (c *Conn) login() error {
 	// Build login line.
-	login := fmt.Sprintf("user %s pass %s vers %s %s", callsign, passcode, appName, appVer)
+	login := fmt.Sprintf("user %s pass %s vers %s %s", c.callsign, c.passcode, c.appName, c.appVer)
 	if c.filter != "" {
 		login += " filter " + c.filter
 	}
 
 	if err := c.SendLine(login); err != nil {
-		tc.Close()
-		return nil, fmt.Errorf("failed to send login: %w", err)
+		return fmt.Errorf("failed to send login: %w", err)
 	}
 
-	// Wait for logresp with a timeout.
-	deadline := time.Now().Add(5 * time.Second)
+	// Wait for logresp with a timeout. The server sends its banner
+	// when the connection is made, before the login response.
+	deadline := time.Now().Add(aprsisLoginTimeout)
+	reason := "connection closed"
 	for time.Now().Before(deadline) {
 		line, err := c.ReadLine(time.Until(deadline))
+		if errors.Is(err, io.EOF) {
+			// Servers close the connection after a comment on why
+			return fmt.Errorf("%w: %s", ErrLoginRejected, reason)
+		}
 		if err != nil {
-			tc.Close()
-			return nil, fmt.Errorf("failed to read login response: %w", err)
+			return fmt.Errorf("failed to read login response: %w", err)
+		}
+		if resp, ok := strings.CutPrefix(line, "# logresp "); ok {
+			return c.parseLogresp(resp)
 		}
-		if strings.HasPrefix(line, "# logresp") {
-			return c, nil
+		if comment, ok := strings.CutPrefix(line, "#"); ok {
+			comment = strings.TrimSpace(comment)
+			if c.Login.Software == "" {
+				c.Login.Software = comment
+			}
+			reason = comment
 		}
 	}
 
-	tc.Close()
-	return nil, fmt.Errorf("login timed out waiting for logresp")
+	return fmt.Errorf("login timed out waiting for logresp")
+}
+
+// parseLogresp parses the login response following "# logresp", such as
+// "N0CALL verified, server T2FINLAND".
+func (c *Conn) parseLogresp(resp string) error {
+	fields := strings.Fields(resp)
+	if len(fields) < 2 {
+		return fmt.Errorf("%w: invalid login response: %q", ErrLoginRejected, resp)
+	}
+
+	switch strings.TrimSuffix(fields[1], ",") {
+	case "verified":
+		c.Login.Verified = true
+	case "unverified":
+		c.Login.Verified = false
+	default:
+		return fmt.Errorf("%w: %s", ErrLoginRejected, resp)
+	}
+	c.Login.Callsign = fields[0]
+
+	for i := 2; i+1 < len(fields); i++ {
+		if fields[i] == "server" {
+			c.Login.Server = strings.TrimSuffix(fields[i+1], ",")
+			break
+		}
+	}
+	return nil
 }
 
 // ReadLine reads a single line from the connection, stripping the
-// trailing CR/LF. The provided timeout sets a read deadline.
+// trailing CR/LF. The provided timeout sets a read deadline. A line which
+// is interrupted by the timeout is completed by the next read.
 func (c *Conn) ReadLine(timeout time.Duration) (string, error) {
 	err := c.conn.SetReadDeadline(time.Now().Add(timeout))
 	if err != nil {
@@ -77,8 +166,11 @@ func (c *Conn) ReadLine(timeout time.Duration) (string, error) {
 	}
 	line, err := c.reader.ReadString('\n')
 	if err != nil {
+		c.partial += line
 		return "", err
 	}
+	line = c.partial + line
+	c.partial = ""
 	return strings.TrimRight(line, "\r\n"), nil
 }
 
@@ -103,6 +195,31 @@ func (c *Conn) SendLine(line string) error {
 	return err
 }
 
+// Filter returns the filter of the connection, sent at login or by
+// SetFilter.
+func (c *Conn) Filter() string {
+	return c.filter
+}
+
+// SetFilter replaces the filter of a live connection by sending a
+// "#filter" command to the server. The filter is checked with
+// ValidateFilter first. An empty filter removes the filter, with a bare
+// "#filter" command.
+func (c *Conn) SetFilter(filter string) error {
+	cmd := "#filter"
+	if filter != "" {
+		if err := ValidateFilter(filter); err != nil {
+			return err
+		}
+		cmd += " " + filter
+	}
+	if err := c.SendLine(cmd); err != nil {
+		return err
+	}
+	c.filter = filter
+	return nil
+}
+
 // Close closes the underlying TCP connection.
 func (c *Conn) Close() error {
 	return c.conn.Close()
@@ -0,0 +1,420 @@
+package fap
+
+import (
+	"errors"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of ClientOpts.
+const (
+	clientDialTimeout      = 10 * time.Second // Timeout for connecting to a server
+	clientKeepaliveTimeout = 60 * time.Second // aprsc sends a keepalive comment every 20 s
+	clientMinBackoff       = time.Second
+	clientMaxBackoff       = 5 * time.Minute
+)
+
+// ErrKeepaliveTimeout is the cause reported when a Client drops a
+// connection on which nothing was received within the keepalive timeout.
+var ErrKeepaliveTimeout = errors.New("no data received from APRS-IS server within keepalive timeout")
+
+// ClientOpts contains the parameters of a Client.
+type ClientOpts struct {
+	Servers  []string // Server addresses (host:port), tried in turn
+	Callsign string
+	Passcode string // Default "-1", receive only
+	AppName  string
+	AppVer   string
+	Filter   string // Filter sent in the login line, optional
+
+	KeepaliveTimeout time.Duration // Reconnect if nothing is received for this long, default 60 s
+	MinBackoff       time.Duration // Delay before retrying a failed connection, default 1 s
+	MaxBackoff       time.Duration // Longest delay between connection attempts, default 5 min
+
+	// OnEvent is called when the client connects, disconnects or fails to
+	// connect. It is called from the goroutine which is reading or writing,
+	// and must not block.
+	OnEvent func(ClientEvent)
+}
+
+// ClientEventKind identifies a ClientEvent.
+type ClientEventKind int
+
+// Client event kinds.
+const (
+	ClientConnected     ClientEventKind = iota // Logged in to a server
+	ClientDisconnected                         // An established connection failed
+	ClientConnectFailed                        // A connection attempt failed
+)
+
+// String returns the name of the event kind.
+func (k ClientEventKind) String() string {
+	switch k {
+	case ClientConnected:
+		return "connected"
+	case ClientDisconnected:
+		return "disconnected"
+	case ClientConnectFailed:
+		return "connect failed"
+	}
+	return "unknown"
+}
+
+// ClientEvent reports a change in the connection state of a Client.
+type ClientEvent struct {
+	Kind   ClientEventKind
+	Server string        // Server address
+	Err    error         // Cause of a disconnect or failed connection
+	Delay  time.Duration // Wait before the next connection attempt, after a failed one
+}
+
+// Client is a managed connection to APRS-IS, for long-running services.
+// It connects to the servers in turn, logs in with the callsign and
+// filter, and reconnects with an exponential backoff when the connection
+// fails or when nothing, not even a keepalive comment, is received within
+// the keepalive timeout while reading; the time spent by the caller
+// between reads does not count. A Client can be read and written
+// concurrently from different goroutines.
+type Client struct {
+	opts ClientOpts
+
+	dialing chan struct{} // held by the goroutine making a connection attempt
+	done    chan struct{} // closed by Close
+
+	mu       sync.Mutex
+	conn     *Conn
+	server   string    // address of the current connection
+	idle     time.Time // start of the wait for data, zero after a line is returned
+	next     int       // index of the next server to try
+	failures int       // consecutive failed connection attempts
+	nextDial time.Time // earliest time of the next connection attempt
+	closed   bool
+}
+
+// NewClient creates a client. The first connection is made by the first
+// read or write.
+func NewClient(opts *ClientOpts) (*Client, error) {
+	if opts == nil || len(opts.Servers) == 0 {
+		return nil, errors.New("no APRS-IS servers given")
+	}
+	if opts.Callsign == "" {
+		return nil, errors.New("no callsign given")
+	}
+
+	c := &Client{
+		opts:    *opts,
+		dialing: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	c.opts.Servers = slices.Clone(opts.Servers)
+	if c.opts.Passcode == "" {
+		c.opts.Passcode = "-1"
+	}
+	if c.opts.KeepaliveTimeout <= 0 {
+		c.opts.KeepaliveTimeout = clientKeepaliveTimeout
+	}
+	if c.opts.MinBackoff <= 0 {
+		c.opts.MinBackoff = clientMinBackoff
+	}
+	if c.opts.MaxBackoff <= 0 {
+		c.opts.MaxBackoff = clientMaxBackoff
+	}
+	c.opts.MaxBackoff = max(c.opts.MaxBackoff, c.opts.MinBackoff)
+
+	return c, nil
+}
+
+// backoff returns the delay after a number of consecutive failed
+// connection attempts: the minimum delay, doubled after each failure up
+// to the maximum.
+func (c *Client) backoff(failures int) time.Duration {
+	d := c.opts.MinBackoff
+	for range failures - 1 {
+		d *= 2
+		if d >= c.opts.MaxBackoff {
+			return c.opts.MaxBackoff
+		}
+	}
+	return d
+}
+
+// event calls the OnEvent callback, if set.
+func (c *Client) event(e ClientEvent) {
+	if c.opts.OnEvent != nil {
+		c.opts.OnEvent(e)
+	}
+}
+
+// connection returns the current connection, connecting to the next
+// server if there is none. A wait for the backoff delay past the deadline
+// fails with os.ErrDeadlineExceeded; a connection attempt which has
+// already started is not limited by the deadline.
+func (c *Client) connection(deadline time.Time) (*Conn, error) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	// Only one goroutine makes connection attempts, the others wait for
+	// its result
+	select {
+	case c.dialing <- struct{}{}:
+	case <-timer.C:
+		return nil, os.ErrDeadlineExceeded
+	}
+	defer func() { <-c.dialing }()
+
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return nil, net.ErrClosed
+		}
+		if c.conn != nil {
+			conn := c.conn
+			c.mu.Unlock()
+			return conn, nil
+		}
+		wait := time.Until(c.nextDial)
+		server := c.opts.Servers[c.next]
+		filter := c.opts.Filter
+		c.mu.Unlock()
+
+		if wait > 0 {
+			if time.Now().Add(wait).After(deadline) {
+				return nil, os.ErrDeadlineExceeded
+			}
+			select {
+			case <-time.After(wait):
+			case <-c.done:
+			}
+			continue
+		}
+
+		conn, err := c.dial(server, filter)
+
+		c.mu.Lock()
+		c.next = (c.next + 1) % len(c.opts.Servers)
+		if err != nil {
+			c.failures++
+			delay := c.backoff(c.failures)
+			c.nextDial = time.Now().Add(delay)
+			c.mu.Unlock()
+			c.event(ClientEvent{Kind: ClientConnectFailed, Server: server, Err: err, Delay: delay})
+			continue
+		}
+		if c.closed {
+			c.mu.Unlock()
+			conn.Close()
+			return nil, net.ErrClosed
+		}
+		c.failures = 0
+		c.nextDial = time.Now().Add(c.opts.MinBackoff)
+		c.conn = conn
+		c.server = server
+		c.idle = time.Now()
+		current := c.opts.Filter
+		c.mu.Unlock()
+
+		c.event(ClientEvent{Kind: ClientConnected, Server: server})
+
+		// The filter was changed during the login
+		if current != filter {
+			if err := conn.SetFilter(current); err != nil {
+				c.drop(conn, err)
+				continue
+			}
+		}
+		return conn, nil
+	}
+}
+
+// dial connects and logs in to a server.
+func (c *Client) dial(server, filter string) (*Conn, error) {
+	tc, err := net.DialTimeout("tcp", server, clientDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := newConn(tc, c.opts.Callsign, c.opts.Passcode, c.opts.AppName, c.opts.AppVer)
+	conn.filter = filter
+	if err := conn.login(); err != nil {
+		tc.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// drop closes a failed connection, so that the next read or write
+// reconnects.
+func (c *Client) drop(conn *Conn, cause error) {
+	c.mu.Lock()
+	if c.conn != conn {
+		c.mu.Unlock()
+		return
+	}
+	c.conn.Close()
+	c.conn = nil
+	server := c.server
+	c.mu.Unlock()
+
+	c.event(ClientEvent{Kind: ClientDisconnected, Server: server, Err: cause})
+}
+
+// ReadLine reads a single line from the server, stripping the trailing
+// CR/LF, and reconnecting as needed. A timeout is returned as a net.Error
+// with Timeout() true, or os.ErrDeadlineExceeded if the client is waiting
+// to reconnect. A connection attempt can take longer than the timeout.
+func (c *Client) ReadLine(timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := c.connection(deadline)
+		if err != nil {
+			return "", err
+		}
+
+		// The keepalive timeout runs while waiting for data, from the
+		// start of the read or the last data received, but not while
+		// the caller is busy between reads
+		c.mu.Lock()
+		if c.idle.IsZero() {
+			c.idle = time.Now()
+		}
+		expiry := c.idle.Add(c.opts.KeepaliveTimeout)
+		c.mu.Unlock()
+
+		line, err := conn.ReadLine(min(time.Until(deadline), time.Until(expiry)))
+
+		c.mu.Lock()
+		if err == nil {
+			c.idle = time.Time{}
+		} else if conn.rxTime.After(c.idle) {
+			// Part of a line was received
+			c.idle = conn.rxTime
+			expiry = c.idle.Add(c.opts.KeepaliveTimeout)
+		}
+		c.mu.Unlock()
+
+		if err == nil {
+			return line, nil
+		}
+		if ne, ok := errors.AsType[net.Error](err); ok && ne.Timeout() {
+			if time.Now().Before(expiry) {
+				return "", err
+			}
+			err = ErrKeepaliveTimeout
+		}
+
+		c.drop(conn, err)
+	}
+}
+
+// ReadPacket reads lines until a non-comment line (one that does not
+// start with '#') is found, and returns it. Comment lines, such as the
+// server keepalives, are skipped. The timeout applies to each individual
+// read.
+func (c *Client) ReadPacket(timeout time.Duration) (string, error) {
+	for {
+		line, err := c.ReadLine(timeout)
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(line, "#") {
+			return line, nil
+		}
+	}
+}
+
+// SendLine writes a line followed by CR/LF to the server, reconnecting
+// first if the connection has failed.
+func (c *Client) SendLine(line string) error {
+	conn, err := c.connection(time.Now().Add(clientDialTimeout))
+	if err != nil {
+		return err
+	}
+	if err := conn.SendLine(line); err != nil {
+		c.drop(conn, err)
+		return err
+	}
+	return nil
+}
+
+// Server returns the address of the server the client is connected to,
+// or an empty string if it is not connected.
+func (c *Client) Server() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return ""
+	}
+	return c.server
+}
+
+// Filter returns the filter of the client, which is sent at login.
+func (c *Client) Filter() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.opts.Filter
+}
+
+// SetFilter replaces the filter of the client. The filter is checked
+// with ValidateFilter, sent to the server if the client is connected, and
+// used again on reconnects. An empty filter removes the filter. If
+// sending it fails, the connection is dropped and the error is returned;
+// the new filter is kept and takes effect at the next login.
+func (c *Client) SetFilter(filter string) error {
+	if filter != "" {
+		if err := ValidateFilter(filter); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	c.opts.Filter = filter
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	if err := conn.SetFilter(filter); err != nil {
+		c.drop(conn, err)
+		return err
+	}
+	return nil
+}
+
+// Login returns the details of the login on the current connection, or
+// a zero LoginResponse if the client is not connected. A login rejected
+// by a server is reported as a failed connection, with an error wrapping
+// ErrLoginRejected.
+func (c *Client) Login() LoginResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return LoginResponse{}
+	}
+	return c.conn.Login
+}
+
+// Close closes the connection. Reads and writes after Close fail with
+// net.ErrClosed.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
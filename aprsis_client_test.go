@@ -0,0 +1,423 @@
+package fap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clientTestLogin reads the login line on a server connection and replies
+// with a logresp. It returns the login line.
+func clientTestLogin(conn net.Conn) string {
+	login, _ := bufio.NewReader(conn).ReadString('\n')
+	fmt.Fprintf(conn, "# logresp N0CALL verified, server T2TEST\r\n")
+	return strings.TrimRight(login, "\r\n")
+}
+
+// clientTestEvents records the events of a Client.
+type clientTestEvents struct {
+	mu     sync.Mutex
+	events []ClientEvent
+}
+
+func (e *clientTestEvents) add(ev ClientEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, ev)
+}
+
+func (e *clientTestEvents) get() []ClientEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]ClientEvent(nil), e.events...)
+}
+
+// clientTestDeadAddr returns an address which refuses connections.
+func clientTestDeadAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestClientReconnect(t *testing.T) {
+	logins := make(chan string, 2)
+	addr := kissTestServer(t,
+		func(conn net.Conn) {
+			logins <- clientTestLogin(conn)
+			fmt.Fprintf(conn, "N0CALL>APRS:>first\r\n")
+			conn.Close()
+		},
+		func(conn net.Conn) {
+			fmt.Fprintf(conn, "# aprsc 2.1.19\r\n")
+			logins <- clientTestLogin(conn)
+			fmt.Fprintf(conn, "N0CALL>APRS:>second\r\n")
+			conn.Read(make([]byte, 1))
+			conn.Close()
+		},
+	)
+	dead := clientTestDeadAddr(t)
+
+	var events clientTestEvents
+	c, err := NewClient(&ClientOpts{
+		Servers:    []string{dead, addr},
+		Callsign:   "N0CALL",
+		AppName:    "gotest",
+		AppVer:     "1.0",
+		Filter:     "r/60.0/25.0/100",
+		MinBackoff: 10 * time.Millisecond,
+		OnEvent:    events.add,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	for _, want := range []string{"N0CALL>APRS:>first", "N0CALL>APRS:>second"} {
+		got, err := c.ReadPacket(2 * time.Second)
+		if err != nil {
+			t.Fatalf("ReadPacket failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("ReadPacket = %q, want %q", got, want)
+		}
+	}
+	if got := c.Server(); got != addr {
+		t.Errorf("Server = %q, want %q", got, addr)
+	}
+	if got := c.Login(); !got.Verified || got.Server != "T2TEST" || got.Software != "aprsc 2.1.19" {
+		t.Errorf("Login = %+v, want verified on T2TEST running aprsc 2.1.19", got)
+	}
+
+	// The login and filter are sent again on reconnect
+	for range 2 {
+		login := <-logins
+		if want := "user N0CALL pass -1 vers gotest 1.0 filter r/60.0/25.0/100"; login != want {
+			t.Errorf("login = %q, want %q", login, want)
+		}
+	}
+
+	// The dead server is tried first, and again after the disconnect
+	want := []struct {
+		kind   ClientEventKind
+		server string
+	}{
+		{ClientConnectFailed, dead},
+		{ClientConnected, addr},
+		{ClientDisconnected, addr},
+		{ClientConnectFailed, dead},
+		{ClientConnected, addr},
+	}
+	got := events.get()
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %d events", got, len(want))
+	}
+	for i, w := range want {
+		if got[i].Kind != w.kind || got[i].Server != w.server {
+			t.Errorf("event %d = %v %s, want %v %s", i, got[i].Kind, got[i].Server, w.kind, w.server)
+		}
+	}
+	if got[0].Err == nil || got[0].Delay != 10*time.Millisecond {
+		t.Errorf("first failure = %+v, want error and 10ms delay", got[0])
+	}
+	if got[3].Delay != 10*time.Millisecond {
+		t.Errorf("delay after reconnect = %v, want backoff reset to 10ms", got[3].Delay)
+	}
+}
+
+func TestClientKeepalive(t *testing.T) {
+	addr := kissTestServer(t,
+		func(conn net.Conn) {
+			clientTestLogin(conn)
+			// Silent until the client gives up
+			conn.Read(make([]byte, 1))
+			conn.Close()
+		},
+		func(conn net.Conn) {
+			clientTestLogin(conn)
+			fmt.Fprintf(conn, "N0CALL>APRS:>alive\r\n")
+			conn.Read(make([]byte, 1))
+			conn.Close()
+		},
+	)
+
+	var events clientTestEvents
+	c, err := NewClient(&ClientOpts{
+		Servers:          []string{addr},
+		Callsign:         "N0CALL",
+		KeepaliveTimeout: 300 * time.Millisecond,
+		MinBackoff:       10 * time.Millisecond,
+		OnEvent:          events.add,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	// A read timeout shorter than the keepalive timeout does not drop the
+	// connection
+	_, err = c.ReadPacket(50 * time.Millisecond)
+	if ne, ok := errors.AsType[net.Error](err); !ok || !ne.Timeout() {
+		t.Fatalf("ReadPacket error = %v, want timeout", err)
+	}
+	if got := events.get(); len(got) != 1 || got[0].Kind != ClientConnected {
+		t.Fatalf("events = %v, want only connected", got)
+	}
+
+	got, err := c.ReadPacket(2 * time.Second)
+	if err != nil {
+		t.Fatalf("ReadPacket failed: %v", err)
+	}
+	if got != "N0CALL>APRS:>alive" {
+		t.Errorf("ReadPacket = %q", got)
+	}
+
+	evs := events.get()
+	if len(evs) != 3 || evs[1].Kind != ClientDisconnected || !errors.Is(evs[1].Err, ErrKeepaliveTimeout) {
+		t.Errorf("events = %v, want disconnect caused by keepalive timeout", evs)
+	}
+}
+
+func TestClientKeepaliveSlowReader(t *testing.T) {
+	addr := kissTestServer(t, func(conn net.Conn) {
+		clientTestLogin(conn)
+		fmt.Fprintf(conn, "N0CALL>APRS:>first\r\n")
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprintf(conn, "N0CALL>APRS:>second\r\n")
+		conn.Read(make([]byte, 1))
+		conn.Close()
+	})
+
+	var events clientTestEvents
+	c, err := NewClient(&ClientOpts{
+		Servers:          []string{addr},
+		Callsign:         "N0CALL",
+		KeepaliveTimeout: 300 * time.Millisecond,
+		OnEvent:          events.add,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	// The time spent between reads is not a keepalive timeout, the second
+	// line is waiting to be read
+	for i, want := range []string{"N0CALL>APRS:>first", "N0CALL>APRS:>second"} {
+		if i > 0 {
+			time.Sleep(500 * time.Millisecond)
+		}
+		got, err := c.ReadPacket(2 * time.Second)
+		if err != nil {
+			t.Fatalf("ReadPacket failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("ReadPacket = %q, want %q", got, want)
+		}
+	}
+
+	if evs := events.get(); len(evs) != 1 || evs[0].Kind != ClientConnected {
+		t.Errorf("events = %v, want only connected", evs)
+	}
+}
+
+func TestClientSetFilter(t *testing.T) {
+	lines := make(chan string, 4)
+	addr := kissTestServer(t,
+		func(conn net.Conn) {
+			r := bufio.NewReader(conn)
+			login, _ := r.ReadString('\n')
+			lines <- login
+			fmt.Fprintf(conn, "# logresp N0CALL verified, server T2TEST\r\n")
+			cmd, _ := r.ReadString('\n')
+			lines <- cmd
+			conn.Close()
+		},
+		func(conn net.Conn) {
+			lines <- clientTestLogin(conn) + "\r\n"
+			fmt.Fprintf(conn, "N0CALL>APRS:>second\r\n")
+			conn.Read(make([]byte, 1))
+			conn.Close()
+		},
+	)
+
+	c, err := NewClient(&ClientOpts{
+		Servers:    []string{addr},
+		Callsign:   "N0CALL",
+		Filter:     "r/60.0/25.0/100",
+		MinBackoff: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	// Changed before connecting, used in the login
+	if err := c.SetFilter("m/50"); err != nil {
+		t.Fatalf("SetFilter failed: %v", err)
+	}
+	if err := c.SendLine("#ping"); err != nil {
+		t.Fatalf("SendLine failed: %v", err)
+	}
+	if err := c.SetFilter("x/1"); !errors.Is(err, ErrFilterInvalid) {
+		t.Errorf("SetFilter error = %v, want %v", err, ErrFilterInvalid)
+	}
+	// Changed while connected, and used again after the reconnect
+	if err := c.SetFilter("m/100 -t/w"); err != nil {
+		t.Fatalf("SetFilter failed: %v", err)
+	}
+	if _, err := c.ReadPacket(2 * time.Second); err != nil {
+		t.Fatalf("ReadPacket failed: %v", err)
+	}
+	if got := c.Filter(); got != "m/100 -t/w" {
+		t.Errorf("Filter = %q", got)
+	}
+
+	want := []string{
+		"user N0CALL pass -1 vers   filter m/50\r\n",
+		"#ping\r\n",
+		"user N0CALL pass -1 vers   filter m/100 -t/w\r\n",
+	}
+	for _, w := range want {
+		if got := <-lines; got != w {
+			t.Errorf("server received %q, want %q", got, w)
+		}
+	}
+}
+
+func TestClientSetFilterError(t *testing.T) {
+	logins := make(chan string, 2)
+	addr := kissTestServer(t,
+		func(conn net.Conn) {
+			logins <- clientTestLogin(conn)
+			conn.Read(make([]byte, 1))
+			conn.Close()
+		},
+		func(conn net.Conn) {
+			logins <- clientTestLogin(conn)
+			fmt.Fprintf(conn, "N0CALL>APRS:>second\r\n")
+			conn.Read(make([]byte, 1))
+			conn.Close()
+		},
+	)
+
+	var events clientTestEvents
+	c, err := NewClient(&ClientOpts{
+		Servers:    []string{addr},
+		Callsign:   "N0CALL",
+		Filter:     "m/50",
+		MinBackoff: 10 * time.Millisecond,
+		OnEvent:    events.add,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.SendLine("#ping"); err != nil {
+		t.Fatalf("SendLine failed: %v", err)
+	}
+
+	// Break the connection under the client, so that sending fails
+	c.mu.Lock()
+	c.conn.conn.Close()
+	c.mu.Unlock()
+
+	if err := c.SetFilter("m/100"); err == nil {
+		t.Errorf("SetFilter on a failed connection succeeded, want error")
+	}
+	if got := c.Filter(); got != "m/100" {
+		t.Errorf("Filter = %q, want %q", got, "m/100")
+	}
+
+	// The new filter is used at the next login
+	if _, err := c.ReadPacket(2 * time.Second); err != nil {
+		t.Fatalf("ReadPacket failed: %v", err)
+	}
+	for _, want := range []string{"filter m/50", "filter m/100"} {
+		if got := <-logins; !strings.HasSuffix(got, want) {
+			t.Errorf("login = %q, want %q", got, want)
+		}
+	}
+	if evs := events.get(); len(evs) != 3 || evs[1].Kind != ClientDisconnected {
+		t.Errorf("events = %v, want connected, disconnected, connected", evs)
+	}
+}
+
+func TestClientBackoff(t *testing.T) {
+	c, err := NewClient(&ClientOpts{
+		Servers:    []string{"localhost:14580"},
+		Callsign:   "N0CALL",
+		MinBackoff: time.Second,
+		MaxBackoff: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+	for _, tc := range tests {
+		if got := c.backoff(tc.failures); got != tc.want {
+			t.Errorf("backoff(%d) = %v, want %v", tc.failures, got, tc.want)
+		}
+	}
+}
+
+func TestClientDeadline(t *testing.T) {
+	c, err := NewClient(&ClientOpts{
+		Servers:    []string{clientTestDeadAddr(t)},
+		Callsign:   "N0CALL",
+		MinBackoff: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	// The first attempt fails, and the next one is not due before the
+	// deadline
+	start := time.Now()
+	_, err = c.ReadPacket(100 * time.Millisecond)
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("ReadPacket error = %v, want %v", err, os.ErrDeadlineExceeded)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("ReadPacket took %v", d)
+	}
+
+	c.Close()
+	if _, err := c.ReadPacket(time.Second); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("ReadPacket after Close error = %v, want %v", err, net.ErrClosed)
+	}
+}
+
+func TestNewClientInvalid(t *testing.T) {
+	tests := []*ClientOpts{
+		nil,
+		{Callsign: "N0CALL"},
+		{Servers: []string{"localhost:14580"}},
+	}
+	for _, opts := range tests {
+		if _, err := NewClient(opts); err == nil {
+			t.Errorf("NewClient(%+v) succeeded, want error", opts)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package fap
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net"
+	"strings"
+	"time"
+)
+
+// streamPollInterval is the read timeout used by streams, which bounds
+// the time taken to notice a cancelled context.
+const streamPollInterval = time.Second
+
+// StreamEventKind identifies a StreamEvent.
+type StreamEventKind int
+
+// Stream event kinds.
+const (
+	StreamPacket     StreamEventKind = iota // A parsed packet
+	StreamParseError                        // A packet which could not be parsed
+	StreamComment                           // A server comment line, starting with '#'
+)
+
+// String returns the name of the event kind.
+func (k StreamEventKind) String() string {
+	switch k {
+	case StreamPacket:
+		return "packet"
+	case StreamParseError:
+		return "parse error"
+	case StreamComment:
+		return "comment"
+	}
+	return "unknown"
+}
+
+// StreamEvent is a line received from an APRS-IS server by a stream.
+type StreamEvent struct {
+	Kind   StreamEventKind
+	Line   string  // The line as received
+	Packet *Packet // The parsed packet; partially filled in for a parse error
+	Err    error   // The parse error, a *ParseError
+}
+
+// Stream returns an iterator over the lines received on the connection,
+// parsed with Parse and the given options. Each line is yielded as a
+// StreamEvent with a nil error. The iteration ends when the context is
+// cancelled, or after yielding a nil event with the error which ended the
+// connection. Stream must not be used at the same time as the read
+// methods of the connection.
+func (c *Conn) Stream(ctx context.Context, opts ...Option) iter.Seq2[*StreamEvent, error] {
+	return func(yield func(*StreamEvent, error) bool) {
+		// Interrupt a blocked read on cancellation
+		stop := context.AfterFunc(ctx, func() {
+			c.conn.SetReadDeadline(time.Now())
+		})
+		defer stop()
+
+		for ctx.Err() == nil {
+			line, err := c.ReadLine(streamPollInterval)
+			if err != nil {
+				if ne, ok := errors.AsType[net.Error](err); ok && ne.Timeout() {
+					continue
+				}
+				if ctx.Err() == nil {
+					yield(nil, err)
+				}
+				return
+			}
+			if !yield(streamEvent(line, opts), nil) {
+				return
+			}
+		}
+	}
+}
+
+// Stream returns an iterator over the lines received from APRS-IS, parsed
+// with Parse and the given options, like Conn.Stream. Connection failures
+// are handled by the client, and the iteration ends when the context is
+// cancelled or the client is closed. Cancellation is noticed within a
+// second, or when an ongoing connection attempt finishes.
+func (c *Client) Stream(ctx context.Context, opts ...Option) iter.Seq2[*StreamEvent, error] {
+	return func(yield func(*StreamEvent, error) bool) {
+		for ctx.Err() == nil {
+			line, err := c.ReadLine(streamPollInterval)
+			if err != nil {
+				// Also os.ErrDeadlineExceeded while waiting to reconnect
+				if ne, ok := errors.AsType[net.Error](err); ok && ne.Timeout() {
+					continue
+				}
+				if !errors.Is(err, net.ErrClosed) {
+					yield(nil, err)
+				}
+				return
+			}
+			if !yield(streamEvent(line, opts), nil) {
+				return
+			}
+		}
+	}
+}
+
+// streamEvent parses a received line into an event.
+func streamEvent(line string, opts []Option) *StreamEvent {
+	if strings.HasPrefix(line, "#") {
+		return &StreamEvent{Kind: StreamComment, Line: line}
+	}
+	p, err := Parse(line, opts...)
+	if err != nil {
+		return &StreamEvent{Kind: StreamParseError, Line: line, Packet: p, Err: err}
+	}
+	return &StreamEvent{Kind: StreamPacket, Line: line, Packet: p}
+}
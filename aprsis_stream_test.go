@@ -0,0 +1,147 @@
+package fap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConnStream(t *testing.T) {
+	lines := []string{
+		"# aprsc 2.1.19-g730c5c0",
+		"N0CALL>APRS:>status",
+		"KD0KZE>TUPX9R,RS0ISS*,qAR,K0GDI-6:'yaIl -/]Greetings via ISS=",
+		"N0CALL>APRS:!bogus",
+	}
+	addr := kissTestServer(t, func(conn net.Conn) {
+		clientTestLogin(conn)
+		for _, line := range lines {
+			fmt.Fprintf(conn, "%s\r\n", line)
+		}
+		conn.Read(make([]byte, 1))
+		conn.Close()
+	})
+
+	c, err := Dial(addr, "N0CALL", "-1", "gotest", "1.0")
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	want := []StreamEventKind{StreamComment, StreamPacket, StreamPacket, StreamParseError}
+	var got []*StreamEvent
+	start := time.Now()
+	for ev, err := range c.Stream(ctx, WithAcceptBrokenMicE()) {
+		if err != nil {
+			t.Fatalf("Stream error: %v", err)
+		}
+		got = append(got, ev)
+		if len(got) == len(want) {
+			// The blocked read is interrupted by the cancellation
+			cancel()
+		}
+	}
+	if d := time.Since(start); d > streamPollInterval/2 {
+		t.Errorf("Stream took %v to stop", d)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d", len(got), len(want))
+	}
+	for i, ev := range got {
+		if ev.Kind != want[i] || ev.Line != lines[i] {
+			t.Errorf("event %d = %v %q, want %v %q", i, ev.Kind, ev.Line, want[i], lines[i])
+		}
+	}
+	if got[1].Packet == nil || got[1].Packet.Status != "status" {
+		t.Errorf("packet = %+v, want status", got[1].Packet)
+	}
+	// Parsed with the WithAcceptBrokenMicE option
+	if got[2].Packet == nil || got[2].Packet.Latitude == nil {
+		t.Errorf("mic-e packet = %+v, want position", got[2].Packet)
+	}
+	if _, ok := errors.AsType[*ParseError](got[3].Err); !ok || got[3].Packet == nil {
+		t.Errorf("parse error event = %+v, want *ParseError and packet", got[3])
+	}
+}
+
+func TestConnStreamEOF(t *testing.T) {
+	addr := kissTestServer(t, func(conn net.Conn) {
+		clientTestLogin(conn)
+		fmt.Fprintf(conn, "N0CALL>APRS:>status\r\n")
+		conn.Close()
+	})
+
+	c, err := Dial(addr, "N0CALL", "-1", "gotest", "1.0")
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer c.Close()
+
+	var events int
+	var streamErr error
+	for ev, err := range c.Stream(t.Context()) {
+		if err != nil {
+			if ev != nil {
+				t.Errorf("event with error = %+v, want nil", ev)
+			}
+			streamErr = err
+			continue
+		}
+		events++
+	}
+	if events != 1 || !errors.Is(streamErr, io.EOF) {
+		t.Errorf("got %d events and error %v, want 1 event and %v", events, streamErr, io.EOF)
+	}
+}
+
+func TestClientStream(t *testing.T) {
+	addr := kissTestServer(t,
+		func(conn net.Conn) {
+			clientTestLogin(conn)
+			fmt.Fprintf(conn, "N0CALL>APRS:>first\r\n")
+			conn.Close()
+		},
+		func(conn net.Conn) {
+			clientTestLogin(conn)
+			fmt.Fprintf(conn, "N0CALL>APRS:>second\r\n")
+			conn.Read(make([]byte, 1))
+			conn.Close()
+		},
+	)
+
+	c, err := NewClient(&ClientOpts{
+		Servers:    []string{addr},
+		Callsign:   "N0CALL",
+		MinBackoff: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	// The stream continues over the reconnect, and ends when the client
+	// is closed
+	var statuses []string
+	for ev, err := range c.Stream(t.Context()) {
+		if err != nil {
+			t.Fatalf("Stream error: %v", err)
+		}
+		if ev.Kind != StreamPacket {
+			continue
+		}
+		statuses = append(statuses, ev.Packet.Status)
+		if len(statuses) == 2 {
+			c.Close()
+		}
+	}
+	if len(statuses) != 2 || statuses[0] != "first" || statuses[1] != "second" {
+		t.Errorf("statuses = %q, want first and second", statuses)
+	}
+}
@@ -1,6 +1,8 @@
 package fap
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"net"
 	"strings"
@@ -134,6 +136,175 @@ func TestDialWithFilter(t *testing.T) {
 	}
 }
 
+func TestDialLogin(t *testing.T) {
+	tests := []struct {
+		name   string
+		banner string
+		lines  []string
+		close  bool // the server closes the connection
+		want   LoginResponse
+		reject string // expected rejection reason
+	}{
+		{
+			name:   "verified",
+			banner: "# aprsc 2.1.19-g730c5c0",
+			lines:  []string{"# logresp N0CALL verified, server T2TEST"},
+			want:   LoginResponse{Callsign: "N0CALL", Verified: true, Server: "T2TEST", Software: "aprsc 2.1.19-g730c5c0"},
+		},
+		{
+			name:  "unverified",
+			lines: []string{"# logresp N0CALL-5 unverified, server T2FINLAND"},
+			want:  LoginResponse{Callsign: "N0CALL-5", Server: "T2FINLAND"},
+		},
+		{
+			name:   "no server",
+			banner: "# javAPRSSrvr 3.15b08",
+			lines:  []string{"# logresp N0CALL verified"},
+			want:   LoginResponse{Callsign: "N0CALL", Verified: true, Software: "javAPRSSrvr 3.15b08"},
+		},
+		{
+			name:   "closed",
+			banner: "# aprsc 2.1.19-g730c5c0",
+			lines:  []string{"# Login by user not allowed"},
+			close:  true,
+			reject: "Login by user not allowed",
+		},
+		{
+			name:   "closed silently",
+			close:  true,
+			reject: "connection closed",
+		},
+		{
+			name:   "invalid logresp",
+			lines:  []string{"# logresp N0CALL"},
+			reject: "invalid login response",
+		},
+		{
+			name:   "unknown status",
+			lines:  []string{"# logresp N0CALL disallowed, server T2TEST"},
+			reject: "N0CALL disallowed, server T2TEST",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			addr := kissTestServer(t, func(conn net.Conn) {
+				if tc.banner != "" {
+					fmt.Fprintf(conn, "%s\r\n", tc.banner)
+				}
+				buf := make([]byte, 1024)
+				conn.Read(buf)
+				for _, line := range tc.lines {
+					fmt.Fprintf(conn, "%s\r\n", line)
+				}
+				if !tc.close {
+					conn.Read(buf)
+				}
+				conn.Close()
+			})
+
+			c, err := Dial(addr, "N0CALL", "13023", "gotest", "1.0")
+			if tc.reject != "" {
+				if !errors.Is(err, ErrLoginRejected) || !strings.Contains(err.Error(), tc.reject) {
+					t.Errorf("Dial error = %v, want %v with %q", err, ErrLoginRejected, tc.reject)
+				}
+				if c != nil {
+					c.Close()
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			defer c.Close()
+			if c.Login != tc.want {
+				t.Errorf("Login = %+v, want %+v", c.Login, tc.want)
+			}
+		})
+	}
+}
+
+func TestConnSetFilter(t *testing.T) {
+	lines := make(chan string, 2)
+	addr := kissTestServer(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		r.ReadString('\n')
+		fmt.Fprintf(conn, "# logresp N0CALL verified, server T2TEST\r\n")
+		for range 2 {
+			line, _ := r.ReadString('\n')
+			lines <- line
+		}
+		r.ReadString('\n')
+		conn.Close()
+	})
+
+	c, err := Dial(addr, "N0CALL", "13023", "gotest", "1.0", "r/60.0/25.0/100")
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer c.Close()
+
+	// An invalid filter is not sent
+	if err := c.SetFilter("r/60.0/25.0/100 x/1"); !errors.Is(err, ErrFilterInvalid) {
+		t.Errorf("SetFilter error = %v, want %v", err, ErrFilterInvalid)
+	}
+	if got := c.Filter(); got != "r/60.0/25.0/100" {
+		t.Errorf("Filter = %q after invalid filter", got)
+	}
+
+	if err := c.SetFilter("r/61.0/25.0/50 -t/w"); err != nil {
+		t.Fatalf("SetFilter failed: %v", err)
+	}
+	if got := <-lines; got != "#filter r/61.0/25.0/50 -t/w\r\n" {
+		t.Errorf("sent %q", got)
+	}
+	if got := c.Filter(); got != "r/61.0/25.0/50 -t/w" {
+		t.Errorf("Filter = %q", got)
+	}
+
+	// An empty filter is sent as a bare command
+	if err := c.SetFilter(""); err != nil {
+		t.Fatalf("SetFilter(\"\") failed: %v", err)
+	}
+	if got := <-lines; got != "#filter\r\n" {
+		t.Errorf("sent %q", got)
+	}
+	if got := c.Filter(); got != "" {
+		t.Errorf("Filter = %q, want empty", got)
+	}
+}
+
+func TestReadLinePartial(t *testing.T) {
+	addr := kissTestServer(t, func(conn net.Conn) {
+		buf := make([]byte, 1024)
+		conn.Read(buf)
+		fmt.Fprintf(conn, "# logresp N0CALL verified, server T2TEST\r\n")
+		fmt.Fprintf(conn, "N0CALL>APRS:>hel")
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprintf(conn, "lo\r\n")
+		conn.Read(buf)
+		conn.Close()
+	})
+
+	c, err := Dial(addr, "N0CALL", "13023", "gotest", "1.0")
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer c.Close()
+
+	// The start of the line is kept over the timeout
+	if _, err := c.ReadLine(50 * time.Millisecond); err == nil {
+		t.Fatalf("ReadLine succeeded, want timeout")
+	}
+	got, err := c.ReadLine(2 * time.Second)
+	if err != nil {
+		t.Fatalf("ReadLine failed: %v", err)
+	}
+	if want := "N0CALL>APRS:>hello"; got != want {
+		t.Errorf("ReadLine = %q, want %q", got, want)
+	}
+}
+
 func TestAprsPasscode(t *testing.T) {
 	tests := []struct {
 		callsign string
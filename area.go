@@ -0,0 +1,75 @@
+package fap
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseSpecialObject parses the data extensions of area objects (symbol
+// \l) and signposts (symbol \m) at the start of an object or item comment.
+// Returns the comment with the extension removed.
+func (p *Packet) parseSpecialObject(comment string) string {
+	if p.SymbolTable != '\\' {
+		return comment
+	}
+	switch p.SymbolCode {
+	case 'l':
+		return p.parseArea(comment)
+	case 'm':
+		return p.parseSignpost(comment)
+	}
+	return comment
+}
+
+// parseArea parses an area object extension: Tyy/Cxx, followed by {www}
+// for line objects.
+//
+//	T  = shape, 0-9
+//	yy = square root of the latitude offset in hundredths of a degree
+//	/  = separator, or 1 for high intensity colours
+//	C  = colour, 0-7
+//	xx = square root of the longitude offset in hundredths of a degree
+//	www = corridor width of a line in miles
+func (p *Packet) parseArea(comment string) string {
+	if len(comment) < 7 || !isDigits(comment[0:3]) || (comment[3] != '/' && comment[3] != '1') ||
+		comment[4] < '0' || comment[4] > '7' || !isDigits(comment[5:7]) {
+		return comment
+	}
+
+	yy, _ := strconv.Atoi(comment[1:3])
+	xx, _ := strconv.Atoi(comment[5:7])
+
+	area := &Area{
+		Shape:     AreaShape(comment[0] - '0'),
+		LatOffset: float64(yy*yy) / 100,
+		LonOffset: float64(xx*xx) / 100,
+		Color:     int(comment[4] - '0'),
+	}
+	if comment[3] == '1' {
+		area.Color += 8
+	}
+	comment = comment[7:]
+
+	if area.Shape == AreaLineRight || area.Shape == AreaLineLeft {
+		if end := strings.IndexByte(comment, '}'); strings.HasPrefix(comment, "{") && end > 1 {
+			if w, err := strconv.ParseFloat(comment[1:end], 64); err == nil && w >= 0 {
+				km := w * 1.609344 // miles to km
+				area.LineWidth = &km
+				comment = comment[end+1:]
+			}
+		}
+	}
+
+	p.Area = area
+	return comment
+}
+
+// parseSignpost parses the {xxx} signpost display text of 1-3 characters.
+func (p *Packet) parseSignpost(comment string) string {
+	end := strings.IndexByte(comment, '}')
+	if !strings.HasPrefix(comment, "{") || end < 2 || end > 4 {
+		return comment
+	}
+	p.Signpost = comment[1:end]
+	return comment[end+1:]
+}
@@ -0,0 +1,175 @@
+package fap
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAreaObject(t *testing.T) {
+	tests := []struct {
+		name          string
+		packet        string
+		wantShape     AreaShape
+		wantLatOffset float64
+		wantLonOffset float64
+		wantColor     int
+		wantLineWidth *float64
+		wantComment   string
+	}{
+		{
+			"circle",
+			"N0CALL>APRS:;CIRCLE   *092345z4903.50N\\07201.75Wl005/307 Exclusion zone",
+			AreaCircle, 0.25, 0.49, 3, nil, "Exclusion zone",
+		},
+		{
+			"filled box, high intensity colour",
+			"N0CALL>APRS:;BOX      *092345z4903.50N\\07201.75Wl9101404",
+			AreaFilledBox, 1, 0.16, 12, nil, "",
+		},
+		{
+			"line with corridor width",
+			"N0CALL>APRS:;ROUTE    *092345z4903.50N\\07201.75Wl615/220{10}Road",
+			AreaLineLeft, 2.25, 4, 2, new(10 * 1.609344), "Road",
+		},
+		{
+			"item",
+			"N0CALL>APRS:)FLOOD!4903.50N\\07201.75Wl312/106",
+			AreaTriangle, 1.44, 0.36, 1, nil, "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Parse(tc.packet)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			a := p.Area
+			if a == nil {
+				t.Fatal("area is nil")
+			}
+			if a.Shape != tc.wantShape {
+				t.Errorf("shape = %d, want %d", a.Shape, tc.wantShape)
+			}
+			if math.Abs(a.LatOffset-tc.wantLatOffset) > 1e-9 || math.Abs(a.LonOffset-tc.wantLonOffset) > 1e-9 {
+				t.Errorf("offsets = %f/%f, want %f/%f", a.LatOffset, a.LonOffset, tc.wantLatOffset, tc.wantLonOffset)
+			}
+			if a.Color != tc.wantColor {
+				t.Errorf("color = %d, want %d", a.Color, tc.wantColor)
+			}
+			if tc.wantLineWidth == nil {
+				if a.LineWidth != nil {
+					t.Errorf("line width = %f, want nil", *a.LineWidth)
+				}
+			} else if a.LineWidth == nil || math.Abs(*a.LineWidth-*tc.wantLineWidth) > 1e-9 {
+				t.Errorf("line width = %v, want %f", a.LineWidth, *tc.wantLineWidth)
+			}
+			if p.Comment != tc.wantComment {
+				t.Errorf("comment = %q, want %q", p.Comment, tc.wantComment)
+			}
+		})
+	}
+}
+
+func TestAreaObjectInvalid(t *testing.T) {
+	for _, packet := range []string{
+		// Not an area symbol
+		"N0CALL>APRS:;CIRCLE   *092345z4903.50N/07201.75Wl005/307",
+		// Invalid separator
+		"N0CALL>APRS:;CIRCLE   *092345z4903.50N\\07201.75Wl005x307",
+		// Invalid colour
+		"N0CALL>APRS:;CIRCLE   *092345z4903.50N\\07201.75Wl005/807",
+		// Not an object or item
+		"N0CALL>APRS:!4903.50N\\07201.75Wl005/307",
+	} {
+		p, err := Parse(packet)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", packet, err)
+		}
+		if p.Area != nil {
+			t.Errorf("%q: area = %+v, want nil", packet, p.Area)
+		}
+	}
+}
+
+func TestSignpost(t *testing.T) {
+	tests := []struct {
+		name         string
+		packet       string
+		wantSignpost string
+		wantComment  string
+	}{
+		{"object", "N0CALL>APRS:;I-95     *092345z4903.50N\\07201.75Wm{55}Speed limit", "55", "Speed limit"},
+		{"item, one character", "N0CALL>APRS:)EXIT!4903.50N\\07201.75Wm{A}", "A", ""},
+		{"too long", "N0CALL>APRS:;SIGN     *092345z4903.50N\\07201.75Wm{1234}", "", "{1234}"},
+		{"empty", "N0CALL>APRS:;SIGN     *092345z4903.50N\\07201.75Wm{}", "", "{}"},
+		{"not a signpost symbol", "N0CALL>APRS:;SIGN     *092345z4903.50N/07201.75Wm{55}", "", "{55}"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Parse(tc.packet)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if p.Signpost != tc.wantSignpost {
+				t.Errorf("signpost = %q, want %q", p.Signpost, tc.wantSignpost)
+			}
+			if p.Comment != tc.wantComment {
+				t.Errorf("comment = %q, want %q", p.Comment, tc.wantComment)
+			}
+		})
+	}
+}
+
+func TestSpecialObjectCompressed(t *testing.T) {
+	area, err := EncodeCompressedPosition(49.0583, -72.0292, nil, nil, nil, "\\l",
+		&EncodePositionOpts{Comment: "005/307 Exclusion zone"})
+	if err != nil {
+		t.Fatalf("EncodeCompressedPosition failed: %v", err)
+	}
+	sign, err := EncodeCompressedPosition(49.0583, -72.0292, nil, nil, nil, "\\m",
+		&EncodePositionOpts{Comment: "{55}Speed limit"})
+	if err != nil {
+		t.Fatalf("EncodeCompressedPosition failed: %v", err)
+	}
+
+	for _, packet := range []string{
+		"N0CALL>APRS:;CIRCLE   *092345z" + area[1:],
+		"N0CALL>APRS:)CIRCLE" + area,
+	} {
+		p, err := Parse(packet)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", packet, err)
+		}
+		if p.Format != FormatCompressed {
+			t.Errorf("format = %q, want %q", p.Format, FormatCompressed)
+		}
+		a := p.Area
+		if a == nil {
+			t.Fatalf("%q: area is nil", packet)
+		}
+		if a.Shape != AreaCircle || a.Color != 3 || math.Abs(a.LatOffset-0.25) > 1e-9 || math.Abs(a.LonOffset-0.49) > 1e-9 {
+			t.Errorf("area = %+v, want circle 0.25/0.49 colour 3", a)
+		}
+		if p.Comment != "Exclusion zone" {
+			t.Errorf("comment = %q, want %q", p.Comment, "Exclusion zone")
+		}
+	}
+
+	for _, packet := range []string{
+		"N0CALL>APRS:;I-95     *092345z" + sign[1:],
+		"N0CALL>APRS:)I-95" + sign,
+	} {
+		p, err := Parse(packet)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", packet, err)
+		}
+		if p.Signpost != "55" {
+			t.Errorf("signpost = %q, want %q", p.Signpost, "55")
+		}
+		if p.Comment != "Speed limit" {
+			t.Errorf("comment = %q, want %q", p.Comment, "Speed limit")
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package fap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AX.25 frame constants.
+const (
+	ax25AddrLen    = 7    // Length of an address field
+	ax25MaxDigis   = 8    // Maximum number of digipeaters
+	ax25ControlUI  = 0x03 // Control field of a UI frame
+	ax25PollFinal  = 0x10 // Poll/final bit of the control field
+	ax25PIDNoLayer = 0xF0 // Protocol identifier: no layer 3 protocol
+	ax25HBit       = 0x80 // Has-been-repeated bit of a digipeater SSID byte
+	ax25LastAddr   = 0x01 // Address extension bit, set on the last address
+)
+
+// DecodeAX25 decodes an AX.25 UI frame, without the FCS, and parses its
+// information field. The returned Packet is the same as Parse would return
+// for the frame in TNC2 format, with AX.25 rules applied as with WithAX25.
+// Digipeaters[].WasDigied is set from the has-been-repeated bits of the
+// frame. Like Parse, DecodeAX25 always returns a Packet, even on failure.
+func DecodeAX25(frame []byte, opts ...Option) (*Packet, error) {
+	var opt options
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	src, dst, digis, info, err := decodeAX25Frame(frame)
+	if err != nil {
+		return &Packet{}, err
+	}
+
+	return parseUIFrame(src, dst, digis, info, &opt)
+}
+
+// parseUIFrame parses the fields of a UI frame received from a radio.
+// The packet is parsed in TNC2 format, in which only the last repeated
+// digipeater is marked with '*', and the has-been-repeated flags of all
+// the digipeaters are then copied to the packet.
+func parseUIFrame(src, dst string, digis []Digipeater, info string, opt *options) (*Packet, error) {
+	var sb strings.Builder
+	sb.WriteString(src + ">" + dst)
+	lastRepeated := -1
+	for i, d := range digis {
+		if d.WasDigied {
+			lastRepeated = i
+		}
+	}
+	for i, d := range digis {
+		sb.WriteString("," + d.Call)
+		if i == lastRepeated {
+			sb.WriteByte('*')
+		}
+	}
+
+	ax25opt := *opt
+	ax25opt.isAX25 = true
+
+	p, err := parse(sb.String()+":"+info, &ax25opt)
+	if len(p.Digipeaters) == len(digis) {
+		for i, d := range digis {
+			p.Digipeaters[i].WasDigied = d.WasDigied
+		}
+	}
+
+	return p, err
+}
+
+// decodeAX25Frame splits an AX.25 UI frame into the source and destination
+// callsigns, the digipeaters and the information field.
+func decodeAX25Frame(frame []byte) (src, dst string, digis []Digipeater, info string, err error) {
+	var calls []string
+	pos := 0
+	for {
+		if len(frame) < pos+ax25AddrLen {
+			return "", "", nil, "", &ParseError{Code: ErrAX25Invalid.Code, Msg: "frame too short for address field"}
+		}
+		addr := frame[pos : pos+ax25AddrLen]
+		pos += ax25AddrLen
+
+		call, err := decodeAX25Addr(addr)
+		if err != nil {
+			return "", "", nil, "", err
+		}
+		calls = append(calls, call)
+		if len(calls) > 2 {
+			digis = append(digis, Digipeater{Call: call, WasDigied: addr[6]&ax25HBit != 0})
+		}
+
+		if addr[6]&ax25LastAddr != 0 {
+			break
+		}
+		if len(calls) == 2+ax25MaxDigis {
+			return "", "", nil, "", &ParseError{Code: ErrAX25Invalid.Code, Msg: "too many digipeaters"}
+		}
+	}
+	if len(calls) < 2 {
+		return "", "", nil, "", &ParseError{Code: ErrAX25Invalid.Code, Msg: "no destination address"}
+	}
+
+	if len(frame) < pos+2 {
+		return "", "", nil, "", &ParseError{Code: ErrAX25Invalid.Code, Msg: "frame too short for control and PID fields"}
+	}
+	if frame[pos]&^ax25PollFinal != ax25ControlUI {
+		return "", "", nil, "", &ParseError{Code: ErrAX25NotUI.Code, Msg: fmt.Sprintf("not a UI frame, control field 0x%02X", frame[pos])}
+	}
+	if frame[pos+1] != ax25PIDNoLayer {
+		return "", "", nil, "", &ParseError{Code: ErrAX25NotUI.Code, Msg: fmt.Sprintf("unsupported protocol identifier 0x%02X", frame[pos+1])}
+	}
+
+	// The destination address comes first in the frame
+	return calls[1], calls[0], digis, string(frame[pos+2:]), nil
+}
+
+// decodeAX25Addr decodes a 7-byte address field into a callsign with an
+// optional SSID. The callsign characters are shifted left by one bit and
+// padded with spaces.
+func decodeAX25Addr(addr []byte) (string, error) {
+	var call []byte
+	for i, b := range addr[:6] {
+		if b&0x01 != 0 {
+			return "", &ParseError{Code: ErrAX25Invalid.Code, Msg: "address extension bit set inside callsign"}
+		}
+		c := b >> 1
+		if c == ' ' {
+			// Padding: the rest of the callsign must be spaces too
+			for _, rest := range addr[i+1 : 6] {
+				if rest>>1 != ' ' {
+					return "", &ParseError{Code: ErrAX25Invalid.Code, Msg: "space inside callsign"}
+				}
+			}
+			break
+		}
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return "", &ParseError{Code: ErrAX25Invalid.Code, Msg: fmt.Sprintf("invalid character 0x%02X in callsign", c)}
+		}
+		call = append(call, c)
+	}
+	if len(call) == 0 {
+		return "", &ParseError{Code: ErrAX25Invalid.Code, Msg: "empty callsign"}
+	}
+
+	if ssid := int(addr[6]>>1) & 0x0F; ssid != 0 {
+		return string(call) + "-" + strconv.Itoa(ssid), nil
+	}
+	return string(call), nil
+}
@@ -0,0 +1,131 @@
+package fap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EncodeAX25Opts contains optional parameters for EncodeAX25 and
+// EncodeAX25FromTNC2.
+type EncodeAX25Opts struct {
+	KISS bool // wrap the frame in a KISS data frame
+	Port int  // KISS port 0-15
+}
+
+// EncodeAX25 creates an AX.25 UI frame, without the FCS, from a source and
+// destination callsign, a digipeater path and a packet body, such as one
+// created by EncodePosition or EncodeMessage. The has-been-repeated bit of
+// each digipeater is set from its WasDigied flag. Callsigns are validated
+// and normalized with CheckAX25Call, and at most 8 digipeaters are allowed.
+func EncodeAX25(src, dst string, digis []Digipeater, body string, opts *EncodeAX25Opts) ([]byte, error) {
+	if opts == nil {
+		opts = &EncodeAX25Opts{}
+	}
+	if body == "" {
+		return nil, &ParseError{Code: ErrAX25EncInvalid.Code, Msg: "empty packet body"}
+	}
+	if len(digis) > ax25MaxDigis {
+		return nil, &ParseError{Code: ErrAX25EncInvalid.Code, Msg: fmt.Sprintf("too many digipeaters: %d", len(digis))}
+	}
+
+	frame := make([]byte, 0, (2+len(digis))*ax25AddrLen+2+len(body))
+
+	// The command bit is set on the destination address of UI frames
+	var err error
+	frame, err = appendAX25Addr(frame, "destination", dst, 0x80, false)
+	if err != nil {
+		return nil, err
+	}
+	frame, err = appendAX25Addr(frame, "source", src, 0, len(digis) == 0)
+	if err != nil {
+		return nil, err
+	}
+	for i, d := range digis {
+		var flags byte
+		if d.WasDigied {
+			flags = ax25HBit
+		}
+		frame, err = appendAX25Addr(frame, "digipeater", d.Call, flags, i == len(digis)-1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	frame = append(frame, ax25ControlUI, ax25PIDNoLayer)
+	frame = append(frame, body...)
+
+	if opts.KISS {
+		return EncodeKISS(&KISSFrame{Port: opts.Port, Command: KISSCmdData, Data: frame})
+	}
+	return frame, nil
+}
+
+// EncodeAX25FromTNC2 creates an AX.25 UI frame from a packet in TNC2
+// format (SRC>DST,PATH:body). A digipeater marked with '*' and all the
+// digipeaters before it get the has-been-repeated bit set.
+func EncodeAX25FromTNC2(raw string, opts *EncodeAX25Opts) ([]byte, error) {
+	src, dst, digis, body, err := splitTNC2(raw)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeAX25(src, dst, digis, body, opts)
+}
+
+// splitTNC2 splits a packet in TNC2 format into the source, destination,
+// digipeaters and body. The callsigns are not validated.
+func splitTNC2(raw string) (src, dst string, digis []Digipeater, body string, err error) {
+	header, body, ok := strings.Cut(raw, ":")
+	if !ok {
+		return "", "", nil, "", &ParseError{Code: ErrAX25EncInvalid.Code, Msg: "no packet body after header"}
+	}
+	src, path, ok := strings.Cut(header, ">")
+	if !ok {
+		return "", "", nil, "", &ParseError{Code: ErrAX25EncInvalid.Code, Msg: "no '>' in header"}
+	}
+
+	parts := strings.Split(path, ",")
+	lastDigied := -1
+	for i, d := range parts[1:] {
+		call, used := strings.CutSuffix(d, "*")
+		if used {
+			lastDigied = i
+		}
+		digis = append(digis, Digipeater{Call: call})
+	}
+	for i := 0; i <= lastDigied; i++ {
+		digis[i].WasDigied = true
+	}
+
+	return src, parts[0], digis, body, nil
+}
+
+// appendAX25Addr appends a 7-byte address field to a frame. flags are
+// ORed into the SSID byte, and the extension bit is set on the last address.
+func appendAX25Addr(frame []byte, role, call string, flags byte, last bool) ([]byte, error) {
+	normalized := CheckAX25Call(call)
+	if normalized == "" {
+		return nil, &ParseError{Code: ErrAX25EncInvalid.Code, Msg: fmt.Sprintf("%s callsign is not a valid AX.25 call: %q", role, call)}
+	}
+
+	base, ssidStr, _ := strings.Cut(normalized, "-")
+	ssid := 0
+	if ssidStr != "" {
+		ssid, _ = strconv.Atoi(ssidStr)
+	}
+
+	for i := range 6 {
+		c := byte(' ')
+		if i < len(base) {
+			c = base[i]
+		}
+		frame = append(frame, c<<1)
+	}
+
+	// The reserved bits 5 and 6 are set
+	ssidByte := 0x60 | byte(ssid)<<1 | flags
+	if last {
+		ssidByte |= ax25LastAddr
+	}
+	return append(frame, ssidByte), nil
+}
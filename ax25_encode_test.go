@@ -0,0 +1,172 @@
+package fap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEncodeAX25Bytes(t *testing.T) {
+	frame, err := EncodeAX25("N0CALL-7", "APRS", []Digipeater{{Call: "WIDE1-1", WasDigied: true}}, ">Hi", nil)
+	if err != nil {
+		t.Fatalf("EncodeAX25 error: %v", err)
+	}
+	want := []byte{
+		'A' << 1, 'P' << 1, 'R' << 1, 'S' << 1, ' ' << 1, ' ' << 1, 0xE0, // command bit set
+		'N' << 1, '0' << 1, 'C' << 1, 'A' << 1, 'L' << 1, 'L' << 1, 0x6E, // SSID 7
+		'W' << 1, 'I' << 1, 'D' << 1, 'E' << 1, '1' << 1, ' ' << 1, 0xE3, // H-bit, SSID 1, last
+		0x03, 0xF0, '>', 'H', 'i',
+	}
+	if !bytes.Equal(frame, want) {
+		t.Errorf("EncodeAX25 =\n% X\nwant\n% X", frame, want)
+	}
+}
+
+func TestEncodeAX25RoundTrip(t *testing.T) {
+	pos, err := EncodePosition(60.4752, 25.0947, nil, nil, nil, "/#", &EncodePositionOpts{Comment: "Test"})
+	if err != nil {
+		t.Fatalf("EncodePosition error: %v", err)
+	}
+	msg, err := EncodeMessage(&Message{Destination: "OH2XYZ", Text: "Hello", ID: "1"})
+	if err != nil {
+		t.Fatalf("EncodeMessage error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		src   string
+		dst   string
+		digis []Digipeater
+		body  string
+	}{
+		{"position", "OH2RDP-1", "APZ001", []Digipeater{{"OH2RDG", true}, {"WIDE2-1", false}}, pos},
+		{"message", "N0CALL", "APRS-15", nil, msg},
+		{"eight digipeaters", "N0CALL", "APRS", []Digipeater{
+			{"D1", true}, {"D2", true}, {"D3", true}, {"D4", false},
+			{"D5", false}, {"D6", false}, {"D7", false}, {"D8-15", false},
+		}, ">status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := EncodeAX25(tt.src, tt.dst, tt.digis, tt.body, nil)
+			if err != nil {
+				t.Fatalf("EncodeAX25 error: %v", err)
+			}
+			p, err := DecodeAX25(frame)
+			if err != nil {
+				t.Fatalf("DecodeAX25 error: %v", err)
+			}
+			if p.SrcCallsign != tt.src || p.DstCallsign != tt.dst || p.Body != tt.body {
+				t.Errorf("decoded %q > %q : %q, want %q > %q : %q",
+					p.SrcCallsign, p.DstCallsign, p.Body, tt.src, tt.dst, tt.body)
+			}
+			if len(p.Digipeaters) != len(tt.digis) {
+				t.Fatalf("digipeaters = %d, want %d", len(p.Digipeaters), len(tt.digis))
+			}
+			for i, d := range tt.digis {
+				if p.Digipeaters[i] != d {
+					t.Errorf("digi %d = %+v, want %+v", i, p.Digipeaters[i], d)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeAX25Normalize(t *testing.T) {
+	frame, err := EncodeAX25("n0call-0", "aprs", []Digipeater{{Call: "wide2-02"}}, ">x", nil)
+	if err != nil {
+		t.Fatalf("EncodeAX25 error: %v", err)
+	}
+	p, err := DecodeAX25(frame)
+	if err != nil {
+		t.Fatalf("DecodeAX25 error: %v", err)
+	}
+	if p.Header != "N0CALL>APRS,WIDE2-2" {
+		t.Errorf("header = %q, want %q", p.Header, "N0CALL>APRS,WIDE2-2")
+	}
+}
+
+func TestEncodeAX25KISS(t *testing.T) {
+	frame, err := EncodeAX25("N0CALL", "APRS", nil, ">status", &EncodeAX25Opts{KISS: true, Port: 3})
+	if err != nil {
+		t.Fatalf("EncodeAX25 error: %v", err)
+	}
+	f, err := DecodeKISS(frame)
+	if err != nil {
+		t.Fatalf("DecodeKISS error: %v", err)
+	}
+	if f.Port != 3 || f.Command != KISSCmdData {
+		t.Errorf("KISS port %d command %d, want 3 %d", f.Port, f.Command, KISSCmdData)
+	}
+	p, err := DecodeAX25(f.Data)
+	if err != nil {
+		t.Fatalf("DecodeAX25 error: %v", err)
+	}
+	if p.Status != "status" {
+		t.Errorf("status = %q, want %q", p.Status, "status")
+	}
+}
+
+func TestEncodeAX25FromTNC2(t *testing.T) {
+	raw := "OH2RDP-1>BEACON-15,OH2RDG,OH2RDK*,WIDE:!6028.51N/02505.68E#PHG7220/RELAY"
+	frame, err := EncodeAX25FromTNC2(raw, nil)
+	if err != nil {
+		t.Fatalf("EncodeAX25FromTNC2 error: %v", err)
+	}
+	p, err := DecodeAX25(frame)
+	if err != nil {
+		t.Fatalf("DecodeAX25 error: %v", err)
+	}
+	if p.OrigPacket != raw {
+		t.Errorf("decoded = %q, want %q", p.OrigPacket, raw)
+	}
+	want := []bool{true, true, false}
+	for i, w := range want {
+		if p.Digipeaters[i].WasDigied != w {
+			t.Errorf("digi %d wasdigied = %v, want %v", i, p.Digipeaters[i].WasDigied, w)
+		}
+	}
+}
+
+func TestEncodeAX25Invalid(t *testing.T) {
+	var nine []Digipeater
+	for i := range 9 {
+		nine = append(nine, Digipeater{Call: fmt.Sprintf("D%d", i)})
+	}
+
+	tests := []struct {
+		name  string
+		src   string
+		dst   string
+		digis []Digipeater
+		body  string
+		msg   string
+	}{
+		{"source", "N0CALL-16", "APRS", nil, ">x", "source"},
+		{"destination", "N0CALL", "TOOLONGX", nil, ">x", "destination"},
+		{"digipeater", "N0CALL", "APRS", []Digipeater{{Call: "qAR,X"}}, ">x", "digipeater"},
+		{"too many digipeaters", "N0CALL", "APRS", nine, ">x", "too many"},
+		{"empty body", "N0CALL", "APRS", nil, "", "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := EncodeAX25(tt.src, tt.dst, tt.digis, tt.body, nil)
+			if !errors.Is(err, ErrAX25EncInvalid) {
+				t.Fatalf("error = %v, want %v", err, ErrAX25EncInvalid)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("error = %q, want it to mention %q", err, tt.msg)
+			}
+		})
+	}
+
+	for _, raw := range []string{"N0CALL>APRS", "N0CALL:>x", "N0CALL>APRS,TCPIP*,qAC,T2FINLAND:>x"} {
+		if _, err := EncodeAX25FromTNC2(raw, nil); !errors.Is(err, ErrAX25EncInvalid) {
+			t.Errorf("EncodeAX25FromTNC2(%q) error = %v, want %v", raw, err, ErrAX25EncInvalid)
+		}
+	}
+}
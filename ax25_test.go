@@ -0,0 +1,202 @@
+package fap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ax25TestAddr builds an AX.25 address field.
+func ax25TestAddr(call string, hbit, last bool) []byte {
+	base, ssidStr, _ := strings.Cut(call, "-")
+	ssid := 0
+	fmt.Sscanf(ssidStr, "%d", &ssid)
+
+	addr := make([]byte, 7)
+	for i := range 6 {
+		c := byte(' ')
+		if i < len(base) {
+			c = base[i]
+		}
+		addr[i] = c << 1
+	}
+	addr[6] = 0x60 | byte(ssid)<<1
+	if hbit {
+		addr[6] |= 0x80
+	}
+	if last {
+		addr[6] |= 0x01
+	}
+	return addr
+}
+
+// ax25TestFrame builds an AX.25 UI frame. Digipeaters ending in '*' have
+// the has-been-repeated bit set.
+func ax25TestFrame(src, dst string, digis []string, info string) []byte {
+	frame := ax25TestAddr(dst, false, false)
+	frame = append(frame, ax25TestAddr(src, false, len(digis) == 0)...)
+	for i, d := range digis {
+		call, hbit := strings.CutSuffix(d, "*")
+		frame = append(frame, ax25TestAddr(call, hbit, i == len(digis)-1)...)
+	}
+	frame = append(frame, 0x03, 0xF0)
+	return append(frame, info...)
+}
+
+func TestDecodeAX25(t *testing.T) {
+	frame := ax25TestFrame("OH2RDP-1", "BEACON-15", []string{"OH2RDG*", "WIDE"}, "!6028.51N/02505.68E#PHG7220/RELAY,WIDE, OH2AP Jarvenpaa")
+	p, err := DecodeAX25(frame)
+	if err != nil {
+		t.Fatalf("DecodeAX25 error: %v", err)
+	}
+
+	if p.SrcCallsign != "OH2RDP-1" {
+		t.Errorf("srccallsign = %q, want %q", p.SrcCallsign, "OH2RDP-1")
+	}
+	if p.DstCallsign != "BEACON-15" {
+		t.Errorf("dstcallsign = %q, want %q", p.DstCallsign, "BEACON-15")
+	}
+	if p.Header != "OH2RDP-1>BEACON-15,OH2RDG*,WIDE" {
+		t.Errorf("header = %q, want %q", p.Header, "OH2RDP-1>BEACON-15,OH2RDG*,WIDE")
+	}
+	if len(p.Digipeaters) != 2 {
+		t.Fatalf("digipeaters = %d, want 2", len(p.Digipeaters))
+	}
+	if p.Digipeaters[0].Call != "OH2RDG" || !p.Digipeaters[0].WasDigied {
+		t.Errorf("digi 0 = %+v, want OH2RDG digied", p.Digipeaters[0])
+	}
+	if p.Digipeaters[1].Call != "WIDE" || p.Digipeaters[1].WasDigied {
+		t.Errorf("digi 1 = %+v, want WIDE not digied", p.Digipeaters[1])
+	}
+	if p.Type != PacketTypeLocation {
+		t.Errorf("type = %q, want %q", p.Type, PacketTypeLocation)
+	}
+	if p.Latitude == nil || fmt.Sprintf("%.4f", *p.Latitude) != "60.4752" {
+		t.Errorf("latitude = %v, want 60.4752", p.Latitude)
+	}
+	if p.Comment != "RELAY,WIDE, OH2AP Jarvenpaa" {
+		t.Errorf("comment = %q, want %q", p.Comment, "RELAY,WIDE, OH2AP Jarvenpaa")
+	}
+}
+
+func TestDecodeAX25HBits(t *testing.T) {
+	// Every repeated digipeater has the H-bit set, only the last one is
+	// marked in the TNC2 header
+	frame := ax25TestFrame("N0CALL", "APRS", []string{"DIGI1*", "DIGI2-3*", "WIDE2-1"}, ">status")
+	p, err := DecodeAX25(frame)
+	if err != nil {
+		t.Fatalf("DecodeAX25 error: %v", err)
+	}
+	if p.Header != "N0CALL>APRS,DIGI1,DIGI2-3*,WIDE2-1" {
+		t.Errorf("header = %q, want %q", p.Header, "N0CALL>APRS,DIGI1,DIGI2-3*,WIDE2-1")
+	}
+	want := []Digipeater{{"DIGI1", true}, {"DIGI2-3", true}, {"WIDE2-1", false}}
+	if len(p.Digipeaters) != len(want) {
+		t.Fatalf("digipeaters = %d, want %d", len(p.Digipeaters), len(want))
+	}
+	for i, d := range want {
+		if p.Digipeaters[i] != d {
+			t.Errorf("digi %d = %+v, want %+v", i, p.Digipeaters[i], d)
+		}
+	}
+}
+
+func TestDecodeAX25MicE(t *testing.T) {
+	// Mic-E uses the destination address, and the info field contains
+	// binary bytes
+	frame := ax25TestFrame("OH7LZB-13", "SX15S6", nil, "'I',l \x1C>/]")
+	p, err := DecodeAX25(frame)
+	if err != nil {
+		t.Fatalf("DecodeAX25 error: %v", err)
+	}
+	if p.Format != FormatMicE {
+		t.Errorf("format = %q, want %q", p.Format, FormatMicE)
+	}
+	if p.Latitude == nil || fmt.Sprintf("%.4f", *p.Latitude) != "-38.2560" {
+		t.Errorf("latitude = %v, want -38.2560", p.Latitude)
+	}
+	if len(p.Digipeaters) != 0 {
+		t.Errorf("digipeaters = %d, want 0", len(p.Digipeaters))
+	}
+}
+
+func TestDecodeAX25Options(t *testing.T) {
+	frame := ax25TestFrame("N0CALL", "APDR16", nil, ">status")
+	p, err := DecodeAX25(frame, WithDeviceID())
+	if err != nil {
+		t.Fatalf("DecodeAX25 error: %v", err)
+	}
+	if p.Device == nil || p.Device.Model != "APRSdroid" {
+		t.Errorf("device = %+v, want APRSdroid", p.Device)
+	}
+}
+
+func TestDecodeAX25Invalid(t *testing.T) {
+	valid := ax25TestFrame("N0CALL", "APRS", nil, ">status")
+
+	notUI := append([]byte{}, valid...)
+	notUI[14] = 0x3F // SABM
+
+	noLayer3 := append([]byte{}, valid...)
+	noLayer3[15] = 0xCF // NET/ROM
+
+	badChar := append([]byte{}, valid...)
+	badChar[8] = '-' << 1
+
+	noLast := ax25TestAddr("APRS", false, false)
+	noLast = append(noLast, ax25TestAddr("N0CALL", false, false)...)
+
+	var tooMany []string
+	for i := range 9 {
+		tooMany = append(tooMany, fmt.Sprintf("DIGI%d", i))
+	}
+
+	tests := []struct {
+		name  string
+		frame []byte
+		want  *ParseError
+	}{
+		{"empty", nil, ErrAX25Invalid},
+		{"short address", valid[:10], ErrAX25Invalid},
+		{"one address", ax25TestAddr("APRS", false, true), ErrAX25Invalid},
+		{"no last address", noLast, ErrAX25Invalid},
+		{"no control", valid[:14], ErrAX25Invalid},
+		{"bad character", badChar, ErrAX25Invalid},
+		{"too many digipeaters", ax25TestFrame("N0CALL", "APRS", tooMany, ">status"), ErrAX25Invalid},
+		{"not UI", notUI, ErrAX25NotUI},
+		{"PID", noLayer3, ErrAX25NotUI},
+		{"empty info", valid[:16], ErrPacketNoBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := DecodeAX25(tt.frame)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+			if p == nil {
+				t.Error("packet is nil")
+			}
+		})
+	}
+}
+
+func TestDecodeAX25FromKISS(t *testing.T) {
+	frame := ax25TestFrame("N0CALL", "APRS", []string{"WIDE1-1"}, ">status")
+	kiss, err := EncodeKISS(&KISSFrame{Port: 1, Data: frame})
+	if err != nil {
+		t.Fatalf("EncodeKISS error: %v", err)
+	}
+	f, err := DecodeKISS(kiss)
+	if err != nil {
+		t.Fatalf("DecodeKISS error: %v", err)
+	}
+	p, err := DecodeAX25(f.Data)
+	if err != nil {
+		t.Fatalf("DecodeAX25 error: %v", err)
+	}
+	if p.Status != "status" || p.Header != "N0CALL>APRS,WIDE1-1" {
+		t.Errorf("packet = %q %q, want %q %q", p.Header, p.Status, "N0CALL>APRS,WIDE1-1", "status")
+	}
+}
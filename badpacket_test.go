@@ -79,16 +79,3 @@ func TestBadPacketBadSymbolTable(t *testing.T) {
 		t.Errorf("error = %v, want %v", err, ErrSymInvTable)
 	}
 }
-
-func TestBadPacketExperimental(t *testing.T) {
-	// Unsupported experimental packet format
-	packet := "ASDF>DSALK,OH2RDG*,WIDE:{{ unsupported experimental format"
-
-	_, err := Parse(packet)
-	if err == nil {
-		t.Fatal("expected error for experimental packet")
-	}
-	if !errors.Is(err, ErrExpUnsupported) {
-		t.Errorf("error = %v, want %v", err, ErrExpUnsupported)
-	}
-}
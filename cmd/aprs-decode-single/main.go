@@ -39,7 +39,7 @@ func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 		return 1
 	}
 
-	p, err := fap.Parse(input)
+	p, err := fap.Parse(input, fap.WithDeviceID())
 
 	if p != nil {
 		printPacket(stdout, p)
@@ -126,6 +126,12 @@ func printPacket(w io.Writer, p *fap.Packet) {
 	if p.RadioRange != nil {
 		fmt.Fprintf(w, "RadioRange:   %.1f km\n", *p.RadioRange)
 	}
+	if p.DFS != "" {
+		fmt.Fprintf(w, "DFS:          %s\n", p.DFS)
+	}
+	if p.DF != nil {
+		printDF(w, p.DF)
+	}
 
 	if p.Timestamp != nil {
 		fmt.Fprintf(w, "Timestamp:    %s\n", p.Timestamp.Format(time.RFC3339))
@@ -143,6 +149,19 @@ func printPacket(w io.Writer, p *fap.Packet) {
 	if p.Alive != nil {
 		fmt.Fprintf(w, "Alive:        %v\n", *p.Alive)
 	}
+	if p.Area != nil {
+		fmt.Fprintf(w, "Area:\n")
+		fmt.Fprintf(w, "  Shape:        %d\n", p.Area.Shape)
+		fmt.Fprintf(w, "  Lat Offset:   %.4f°\n", p.Area.LatOffset)
+		fmt.Fprintf(w, "  Lon Offset:   %.4f°\n", p.Area.LonOffset)
+		fmt.Fprintf(w, "  Color:        %d\n", p.Area.Color)
+		if p.Area.LineWidth != nil {
+			fmt.Fprintf(w, "  Line Width:   %.1f km\n", *p.Area.LineWidth)
+		}
+	}
+	if p.Signpost != "" {
+		fmt.Fprintf(w, "Signpost:     %s\n", p.Signpost)
+	}
 
 	if p.Message != nil {
 		fmt.Fprintf(w, "Message:\n")
@@ -165,6 +184,22 @@ func printPacket(w io.Writer, p *fap.Packet) {
 		fmt.Fprintf(w, "Status:       %s\n", p.Status)
 	}
 
+	if p.UserDefined != nil {
+		fmt.Fprintf(w, "User-defined:\n")
+		fmt.Fprintf(w, "  User ID:     %c\n", p.UserDefined.UserID)
+		fmt.Fprintf(w, "  Packet Type: %c\n", p.UserDefined.PacketType)
+		fmt.Fprintf(w, "  Payload:     %s\n", p.UserDefined.Payload)
+	}
+
+	if p.Query != nil {
+		fmt.Fprintf(w, "Query:\n")
+		fmt.Fprintf(w, "  Type:        %s\n", p.Query.Type)
+		fmt.Fprintf(w, "  Directed:    %v\n", p.Query.Directed)
+		if p.Query.Radius != nil {
+			fmt.Fprintf(w, "  Footprint:   %.4f,%.4f %.1f km\n", *p.Query.Latitude, *p.Query.Longitude, *p.Query.Radius)
+		}
+	}
+
 	if p.Wx != nil {
 		printWeather(w, p.Wx)
 	}
@@ -173,6 +208,10 @@ func printPacket(w io.Writer, p *fap.Packet) {
 		printTelemetry(w, p.TelemetryData)
 	}
 
+	if p.TelemetryDef != nil {
+		printTelemetryDefinition(w, p.TelemetryDef)
+	}
+
 	if p.Capabilities != nil {
 		fmt.Fprintf(w, "Capabilities:\n")
 		for k, v := range p.Capabilities {
@@ -205,6 +244,54 @@ func printPacket(w io.Writer, p *fap.Packet) {
 	if p.Comment != "" {
 		fmt.Fprintf(w, "Comment:      %s\n", p.Comment)
 	}
+
+	if p.Device != nil {
+		printDevice(w, p.Device)
+	}
+
+	if p.ThirdParty != nil {
+		fmt.Fprintf(w, "\nThird-party packet:\n")
+		printPacket(w, p.ThirdParty)
+	}
+}
+
+func printDevice(w io.Writer, d *fap.Device) {
+	fmt.Fprintf(w, "Device:       %s %s", d.Vendor, d.Model)
+	if d.Class != "" {
+		fmt.Fprintf(w, " (%s)", d.Class)
+	}
+	if d.OS != "" {
+		fmt.Fprintf(w, " on %s", d.OS)
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+func printDF(w io.Writer, df *fap.DirectionFinding) {
+	fmt.Fprintf(w, "Direction Finding:\n")
+	if df.Bearing != nil {
+		fmt.Fprintf(w, "  Bearing:      %d°\n", *df.Bearing)
+	}
+	if df.Hits != nil {
+		fmt.Fprintf(w, "  Hits:         %d\n", *df.Hits)
+	}
+	if df.Range != nil {
+		fmt.Fprintf(w, "  Range:        %.1f km\n", *df.Range)
+	}
+	if df.Quality != nil {
+		fmt.Fprintf(w, "  Quality:      %d\n", *df.Quality)
+	}
+	if df.Strength != nil {
+		fmt.Fprintf(w, "  Strength:     S%d\n", *df.Strength)
+	}
+	if df.Height != nil {
+		fmt.Fprintf(w, "  Height:       %.1f m\n", *df.Height)
+	}
+	if df.Gain != nil {
+		fmt.Fprintf(w, "  Gain:         %d dB\n", *df.Gain)
+	}
+	if df.Directivity != nil {
+		fmt.Fprintf(w, "  Directivity:  %d°\n", *df.Directivity)
+	}
 }
 
 func printWeather(w io.Writer, wx *fap.Weather) {
@@ -278,3 +365,22 @@ func printTelemetry(w io.Writer, t *fap.Telemetry) {
 		fmt.Fprintf(w, "  Bits:   %s\n", t.Bits)
 	}
 }
+
+func printTelemetryDefinition(w io.Writer, d *fap.TelemetryDefinition) {
+	fmt.Fprintf(w, "Telemetry Definition:\n")
+	if len(d.Names) > 0 {
+		fmt.Fprintf(w, "  Names:    %s\n", strings.Join(d.Names, ","))
+	}
+	if len(d.Units) > 0 {
+		fmt.Fprintf(w, "  Units:    %s\n", strings.Join(d.Units, ","))
+	}
+	for i, eq := range d.Eqns {
+		fmt.Fprintf(w, "  Eqn %d:    %g*x^2 + %g*x + %g\n", i+1, eq.A, eq.B, eq.C)
+	}
+	if d.BitSense != "" {
+		fmt.Fprintf(w, "  BitSense: %s\n", d.BitSense)
+	}
+	if d.Project != "" {
+		fmt.Fprintf(w, "  Project:  %s\n", d.Project)
+	}
+}
@@ -94,6 +94,16 @@ func TestStdinInput(t *testing.T) {
 				"RejID:       1",
 			},
 		},
+		{
+			name:   "telemetry equations",
+			packet: "N0CALL>APRS::N0CALL   :EQNS.0,0.075,0,0,1,-40",
+			wantStrs: []string{
+				"Type:         telemetry-message",
+				"Telemetry Definition:",
+				"Eqn 1:    0*x^2 + 0.075*x + 0",
+				"Eqn 2:    0*x^2 + 1*x + -40",
+			},
+		},
 		{
 			name:   "status",
 			packet: "N0CALL-14>APU25N,WIDE2-2,qAR,LANSNG:>051421>>Nashville,TN>>Toronto,ON",
@@ -116,6 +126,66 @@ func TestStdinInput(t *testing.T) {
 				"MicE Bits:    110",
 			},
 		},
+		{
+			name:   "DF report",
+			packet: "N0CALL>APRS:!4903.50N/07201.75W\\088/036/270/729",
+			wantStrs: []string{
+				"Direction Finding:",
+				"Bearing:      270°",
+				"Hits:         7",
+				"Range:        6.4 km",
+				"Quality:      9",
+			},
+		},
+		{
+			name:   "area object",
+			packet: "N0CALL>APRS:;CIRCLE   *092345z4903.50N\\07201.75Wl005/307 Exclusion zone",
+			wantStrs: []string{
+				"Area:",
+				"Shape:        0",
+				"Lat Offset:   0.2500°",
+				"Color:        3",
+				"Comment:      Exclusion zone",
+			},
+		},
+		{
+			name:   "query",
+			packet: "N0CALL>APRS:?WX? 34.02,-117.15,0200",
+			wantStrs: []string{
+				"Type:         query",
+				"Type:        WX",
+				"Directed:    false",
+				"Footprint:   34.0200,-117.1500 321.9 km",
+			},
+		},
+		{
+			name:   "user-defined",
+			packet: "N0CALL>APRS:{Q1qwerty",
+			wantStrs: []string{
+				"Type:         user-defined",
+				"User ID:     Q",
+				"Packet Type: 1",
+				"Payload:     qwerty",
+			},
+		},
+		{
+			name:   "device",
+			packet: "OH7LZB-2>TQ4W2V,WIDE2-1,qAo,OH7LZB:`c51!f?>/]\"3x}Hello=",
+			wantStrs: []string{
+				"Comment:      Hello",
+				"Device:       Kenwood TM-D710 (rig)",
+			},
+		},
+		{
+			name:   "third-party",
+			packet: "OH2XYZ>APRS,qAR,OH2IGT:}N0CALL-9>APRS,TCPIP,OH2XYZ*:>Hello",
+			wantStrs: []string{
+				"Type:         third-party",
+				"Third-party packet:",
+				"Source:       N0CALL-9",
+				"Status:       Hello",
+			},
+		},
 		{
 			name:   "position with PHG",
 			packet: "N0CALL-1>BEACON-15,N0CALL-2*,WIDE:!6028.51N/02505.68E#PHG7220/RELAY,WIDE, OH2AP Jarvenpaa",
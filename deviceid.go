@@ -0,0 +1,257 @@
+package fap
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Device identifies the radio or software which sent a packet.
+type Device struct {
+	Vendor string `json:"vendor,omitempty"` // Manufacturer or author
+	Model  string `json:"model,omitempty"`  // Model or program name
+	Class  string `json:"class,omitempty"`  // Device class, e.g. "ht", "rig", "software", "tracker"
+	OS     string `json:"os,omitempty"`     // Operating system of software and apps
+}
+
+// legacyMicEDevice is a legacy Mic-E device, identified by a prefix and an
+// optional suffix in the comment.
+type legacyMicEDevice struct {
+	Device
+	Prefix string `json:"prefix"`
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// tocallPattern is a destination callsign pattern. In the pattern '?'
+// matches any character, 'n' matches a digit and a trailing '*' matches
+// the rest of the callsign.
+type tocallPattern struct {
+	pattern string
+	device  *Device
+}
+
+// DeviceTable maps packet destination callsigns (tocalls) and Mic-E
+// comment markers to devices. A DeviceTable is not modified after it has
+// been created and is safe for concurrent use.
+type DeviceTable struct {
+	tocalls    map[string]*Device
+	patterns   []tocallPattern // tocall patterns, most specific first
+	mice       map[string]*Device
+	miceLegacy []legacyMicEDevice // most specific first
+}
+
+// deviceTableJSON is the file format of a device table. It is the layout
+// of the tocalls.dense.json file published by the APRS device
+// identification project; other keys of the file are ignored.
+type deviceTableJSON struct {
+	Tocalls    map[string]*Device          `json:"tocalls"`
+	Mice       map[string]*Device          `json:"mice"`
+	MiceLegacy map[string]legacyMicEDevice `json:"micelegacy"`
+}
+
+// The embedded table is taken from the tocalls.dense.json file of the APRS
+// device identification project, licensed under CC BY-SA 2.0. go generate
+// replaces it with the latest version of the file.
+//
+//go:generate curl -fsSL -o tocalls.dense.json https://raw.githubusercontent.com/aprsorg/aprs-deviceid/main/generated/tocalls.dense.json
+//go:embed tocalls.dense.json
+var defaultDeviceTableJSON []byte
+
+var defaultDeviceTable = sync.OnceValue(func() *DeviceTable {
+	t, err := LoadDeviceTable(bytes.NewReader(defaultDeviceTableJSON))
+	if err != nil {
+		panic("fap: invalid embedded device table: " + err.Error())
+	}
+	return t
+})
+
+// DefaultDeviceTable returns the device table embedded in the package.
+func DefaultDeviceTable() *DeviceTable {
+	return defaultDeviceTable()
+}
+
+// LoadDeviceTable reads a device table in the JSON format of the
+// tocalls.dense.json file of the APRS device identification project:
+// an object with "tocalls", "mice" and "micelegacy" objects. Only JSON is
+// supported; the YAML master file of the project must be converted to
+// JSON first.
+func LoadDeviceTable(r io.Reader) (*DeviceTable, error) {
+	var in deviceTableJSON
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("invalid device table: %w", err)
+	}
+
+	t := &DeviceTable{
+		tocalls: make(map[string]*Device),
+		mice:    make(map[string]*Device),
+	}
+	for pattern, dev := range in.Tocalls {
+		if dev == nil || pattern == "" {
+			continue
+		}
+		if strings.ContainsAny(pattern, "?n*") {
+			t.patterns = append(t.patterns, tocallPattern{pattern: pattern, device: dev})
+		} else {
+			t.tocalls[pattern] = dev
+		}
+	}
+	for suffix, dev := range in.Mice {
+		if dev == nil || len(suffix) != 2 {
+			return nil, fmt.Errorf("invalid device table: Mic-E suffix %q is not 2 characters", suffix)
+		}
+		t.mice[suffix] = dev
+	}
+	for key, dev := range in.MiceLegacy {
+		if dev.Prefix == "" {
+			return nil, fmt.Errorf("invalid device table: legacy Mic-E device %q has no prefix", key)
+		}
+		t.miceLegacy = append(t.miceLegacy, dev)
+	}
+	t.sort()
+
+	return t, nil
+}
+
+// Merge returns a new table containing the entries of both tables. Entries
+// of other take precedence over the entries of t, so a small table of
+// additions and corrections can be merged over the default table.
+func (t *DeviceTable) Merge(other *DeviceTable) *DeviceTable {
+	m := &DeviceTable{
+		tocalls: maps.Clone(t.tocalls),
+		mice:    maps.Clone(t.mice),
+	}
+	maps.Copy(m.tocalls, other.tocalls)
+	maps.Copy(m.mice, other.mice)
+
+	m.patterns = slices.Clone(other.patterns)
+	for _, tp := range t.patterns {
+		if !slices.ContainsFunc(other.patterns, func(o tocallPattern) bool { return o.pattern == tp.pattern }) {
+			m.patterns = append(m.patterns, tp)
+		}
+	}
+	m.miceLegacy = slices.Clone(other.miceLegacy)
+	for _, ld := range t.miceLegacy {
+		if !slices.ContainsFunc(other.miceLegacy, func(o legacyMicEDevice) bool {
+			return o.Prefix == ld.Prefix && o.Suffix == ld.Suffix
+		}) {
+			m.miceLegacy = append(m.miceLegacy, ld)
+		}
+	}
+	m.sort()
+
+	return m
+}
+
+// sort orders the patterns so that the most specific ones are tried first:
+// patterns with more literal characters, then longer patterns.
+func (t *DeviceTable) sort() {
+	slices.SortFunc(t.patterns, func(a, b tocallPattern) int {
+		if c := literalChars(b.pattern) - literalChars(a.pattern); c != 0 {
+			return c
+		}
+		if c := len(b.pattern) - len(a.pattern); c != 0 {
+			return c
+		}
+		return strings.Compare(a.pattern, b.pattern)
+	})
+	slices.SortFunc(t.miceLegacy, func(a, b legacyMicEDevice) int {
+		if c := len(b.Prefix+b.Suffix) - len(a.Prefix+a.Suffix); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Prefix+a.Suffix, b.Prefix+b.Suffix)
+	})
+}
+
+// literalChars returns the number of non-wildcard characters in a tocall
+// pattern.
+func literalChars(pattern string) int {
+	n := 0
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '?' && pattern[i] != 'n' && pattern[i] != '*' {
+			n++
+		}
+	}
+	return n
+}
+
+// matchTocall reports whether a callsign matches a tocall pattern.
+func matchTocall(pattern, call string) bool {
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '*' {
+			return true
+		}
+		if i >= len(call) {
+			return false
+		}
+		switch pattern[i] {
+		case '?':
+		case 'n':
+			if call[i] < '0' || call[i] > '9' {
+				return false
+			}
+		default:
+			if pattern[i] != call[i] {
+				return false
+			}
+		}
+	}
+	return len(call) == len(pattern)
+}
+
+// LookupTocall returns the device using a destination callsign, or nil if
+// the callsign is not known. An SSID is ignored.
+func (t *DeviceTable) LookupTocall(call string) *Device {
+	call = strings.ToUpper(call)
+	if i := strings.IndexByte(call, '-'); i >= 0 {
+		call = call[:i]
+	}
+	if dev := t.tocalls[call]; dev != nil {
+		return new(*dev)
+	}
+	for _, tp := range t.patterns {
+		if matchTocall(tp.pattern, call) {
+			return new(*tp.device)
+		}
+	}
+	return nil
+}
+
+// LookupMicE identifies the device from the comment of a Mic-E packet.
+// It returns the device and the comment with the device markers removed,
+// or nil and the unmodified comment if the comment does not identify a
+// device.
+//
+// Newer devices start the comment with ` or ' and end it with a
+// 2-character device code. Legacy Kenwood radios start the comment with
+// > or ], optionally followed by a suffix character at the end.
+func (t *DeviceTable) LookupMicE(comment string) (*Device, string) {
+	if len(comment) >= 3 && (comment[0] == '`' || comment[0] == '\'') {
+		if dev := t.mice[comment[len(comment)-2:]]; dev != nil {
+			return new(*dev), comment[1 : len(comment)-2]
+		}
+	}
+	for _, ld := range t.miceLegacy {
+		if len(comment) >= len(ld.Prefix)+len(ld.Suffix) &&
+			strings.HasPrefix(comment, ld.Prefix) && strings.HasSuffix(comment, ld.Suffix) {
+			return new(ld.Device), comment[len(ld.Prefix) : len(comment)-len(ld.Suffix)]
+		}
+	}
+	return nil, comment
+}
+
+// identifyDevice sets the device of a parsed packet. Mic-E packets are
+// identified from the comment, which is stripped of the device markers,
+// and other packets from the destination callsign.
+func (p *Packet) identifyDevice(t *DeviceTable) {
+	if p.Format == FormatMicE {
+		p.Device, p.Comment = t.LookupMicE(p.Comment)
+	} else {
+		p.Device = t.LookupTocall(p.DstCallsign)
+	}
+}
@@ -0,0 +1,205 @@
+package fap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeviceIDTocall(t *testing.T) {
+	tests := []struct {
+		dst    string
+		vendor string
+		model  string
+		class  string
+	}{
+		{"APDR16", "Open Source", "APRSdroid", "app"},
+		{"APK004", "Kenwood", "TH-D74", "ht"},        // exact entry wins over APK0??
+		{"APK002", "Kenwood", "TH-D7", "ht"},         // pattern
+		{"APY400-1", "Yaesu", "FTM-400", "rig"},      // SSID is ignored
+		{"APTT", "Byonics", "TinyTrack", "tracker"},  // trailing * matches nothing
+		{"APTT4", "Byonics", "TinyTrack", "tracker"}, // trailing * matches the rest
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dst, func(t *testing.T) {
+			p, err := Parse("N0CALL>"+tt.dst+":>status", WithDeviceID())
+			if err != nil {
+				t.Fatalf("failed to parse packet: %v", err)
+			}
+			if p.Device == nil {
+				t.Fatal("device is nil")
+			}
+			if p.Device.Vendor != tt.vendor || p.Device.Model != tt.model || p.Device.Class != tt.class {
+				t.Errorf("device = %+v, want %s %s %s", *p.Device, tt.vendor, tt.model, tt.class)
+			}
+		})
+	}
+}
+
+func TestDeviceIDUnknown(t *testing.T) {
+	for _, dst := range []string{"APRS", "APDR1", "APK0A", "APY999", "BEACON"} {
+		p, err := Parse("N0CALL>"+dst+":>status", WithDeviceID())
+		if err != nil {
+			t.Fatalf("failed to parse packet: %v", err)
+		}
+		if p.Device != nil {
+			t.Errorf("%s: device = %+v, want nil", dst, *p.Device)
+		}
+	}
+}
+
+func TestDeviceIDDisabled(t *testing.T) {
+	p, err := Parse("OH7LZB-2>TQ4W2V,WIDE2-1,qAo,OH7LZB:`c51!f?>/]\"3x}=")
+	if err != nil {
+		t.Fatalf("failed to parse mic-e packet: %v", err)
+	}
+	if p.Device != nil {
+		t.Errorf("device = %+v, want nil", *p.Device)
+	}
+	if p.Comment != "]=" {
+		t.Errorf("comment = %q, want %q", p.Comment, "]=")
+	}
+}
+
+func TestDeviceIDMicE(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		model   string
+		comment string
+	}{
+		{"legacy with suffix", "`c51!f?>/]\"3x}=", "TM-D710", ""},
+		{"legacy without suffix", "`c51!f?>/]Hello", "TM-D700", "Hello"},
+		{"legacy handheld", "`c51!f?>/>Hello^", "TH-D74", "Hello"},
+		{"new style", "`c51!f?>/`Hello_%", "FTM-400DR", "Hello"},
+		{"new style no messaging", "`c51!f?>/'Hello_(", "FT2D", "Hello"},
+		{"unknown", "`c51!f?>/Hello", "", "Hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("OH7LZB-2>TQ4W2V:"+tt.body, WithDeviceID())
+			if err != nil {
+				t.Fatalf("failed to parse mic-e packet: %v", err)
+			}
+			if tt.model == "" {
+				if p.Device != nil {
+					t.Errorf("device = %+v, want nil", *p.Device)
+				}
+			} else if p.Device == nil {
+				t.Errorf("device is nil, want %s", tt.model)
+			} else if p.Device.Model != tt.model {
+				t.Errorf("model = %q, want %q", p.Device.Model, tt.model)
+			}
+			if p.Comment != tt.comment {
+				t.Errorf("comment = %q, want %q", p.Comment, tt.comment)
+			}
+		})
+	}
+}
+
+func TestDeviceIDMicEEncoded(t *testing.T) {
+	dst, body, err := EncodeMicE(60.1234, 24.5678, nil, nil, "/>", &EncodeMicEOpts{
+		Altitude:     new(-20.0),
+		Comment:      "comment text",
+		DeviceSuffix: "_\"",
+	})
+	if err != nil {
+		t.Fatalf("EncodeMicE failed: %v", err)
+	}
+	p, err := Parse("N0CALL>"+dst+":"+body, WithDeviceID())
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", body, err)
+	}
+	if p.Device == nil || p.Device.Vendor != "Yaesu" || p.Device.Model != "FTM-350" {
+		t.Errorf("device = %+v, want Yaesu FTM-350", p.Device)
+	}
+	if p.Comment != "comment text" {
+		t.Errorf("comment = %q, want %q", p.Comment, "comment text")
+	}
+}
+
+func TestDeviceTableLoad(t *testing.T) {
+	table, err := LoadDeviceTable(strings.NewReader(`{
+		"tocalls": {"APXYZ?": {"vendor": "Test", "model": "Tracker", "class": "tracker"}},
+		"mice": {"Z1": {"vendor": "Test", "model": "Radio", "class": "rig"}},
+		"micelegacy": {"}": {"prefix": "}", "vendor": "Test", "model": "Legacy"}}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to load device table: %v", err)
+	}
+
+	p, err := Parse("N0CALL>APXYZ1:>status", WithDeviceTable(table))
+	if err != nil {
+		t.Fatalf("failed to parse packet: %v", err)
+	}
+	if p.Device == nil || p.Device.Model != "Tracker" {
+		t.Errorf("device = %+v, want Tracker", p.Device)
+	}
+
+	// Entries of the default table are not included
+	p, err = Parse("N0CALL>APDR16:>status", WithDeviceTable(table))
+	if err != nil {
+		t.Fatalf("failed to parse packet: %v", err)
+	}
+	if p.Device != nil {
+		t.Errorf("device = %+v, want nil", *p.Device)
+	}
+
+	if dev, comment := table.LookupMicE("`text Z1"); dev == nil || dev.Model != "Radio" || comment != "text " {
+		t.Errorf("LookupMicE = %+v %q, want Radio %q", dev, comment, "text ")
+	}
+}
+
+func TestDeviceTableMerge(t *testing.T) {
+	override, err := LoadDeviceTable(strings.NewReader(`{
+		"tocalls": {
+			"APDR??": {"vendor": "Test", "model": "Droid"},
+			"APXYZ1": {"vendor": "Test", "model": "Tracker"}
+		},
+		"micelegacy": {"]=": {"prefix": "]", "suffix": "=", "vendor": "Test", "model": "Mobile"}}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to load device table: %v", err)
+	}
+	table := DefaultDeviceTable().Merge(override)
+
+	tests := []struct {
+		call  string
+		model string
+	}{
+		{"APDR16", "Droid"},
+		{"APXYZ1", "Tracker"},
+		{"APK004", "TH-D74"},
+	}
+	for _, tt := range tests {
+		if dev := table.LookupTocall(tt.call); dev == nil || dev.Model != tt.model {
+			t.Errorf("LookupTocall(%q) = %+v, want %s", tt.call, dev, tt.model)
+		}
+	}
+
+	if dev, _ := table.LookupMicE("]="); dev == nil || dev.Model != "Mobile" {
+		t.Errorf("LookupMicE(%q) = %+v, want Mobile", "]=", dev)
+	}
+	if dev, _ := table.LookupMicE("]"); dev == nil || dev.Model != "TM-D700" {
+		t.Errorf("LookupMicE(%q) = %+v, want TM-D700", "]", dev)
+	}
+
+	// The default table is not modified
+	if dev := DefaultDeviceTable().LookupTocall("APDR16"); dev == nil || dev.Model != "APRSdroid" {
+		t.Errorf("default LookupTocall = %+v, want APRSdroid", dev)
+	}
+}
+
+func TestDeviceTableLoadInvalid(t *testing.T) {
+	tests := []string{
+		`not json`,
+		`{"mice": {"ABC": {"vendor": "Test"}}}`,
+		`{"micelegacy": {"x": {"vendor": "Test"}}}`,
+	}
+	for _, in := range tests {
+		if _, err := LoadDeviceTable(strings.NewReader(in)); err == nil {
+			t.Errorf("LoadDeviceTable(%q) succeeded, want error", in)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package fap
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// df returns the direction finding data of the packet, allocating it if
+// needed.
+func (p *Packet) df() *DirectionFinding {
+	if p.DF == nil {
+		p.DF = &DirectionFinding{}
+	}
+	return p.DF
+}
+
+// parseDFS parses a DFSshgd omni-DF signal strength data extension at the
+// start of a comment: signal strength in S-points, antenna height, gain
+// and directivity, the last three coded as in PHG.
+// Returns the comment with the extension removed.
+func (p *Packet) parseDFS(comment string) string {
+	if !strings.HasPrefix(comment, "DFS") || len(comment) < 7 || !isDigits(comment[3:7]) {
+		return comment
+	}
+
+	p.DFS = comment[3:7]
+	df := p.df()
+
+	s := int(comment[3] - '0')
+	df.Strength = &s
+
+	h := 10 * math.Pow(2, float64(comment[4]-'0')) * 0.3048 // feet to meters
+	df.Height = &h
+
+	g := int(comment[5] - '0')
+	df.Gain = &g
+
+	// 0 = omni, 1-8 = 45-360 degrees, 9 = undefined
+	if d := int(comment[6] - '0'); d <= 8 {
+		d *= 45
+		df.Directivity = &d
+	}
+
+	return comment[7:]
+}
+
+// parseDFBearing parses the /BRG/NRQ extension of a DF report at the start
+// of a comment: bearing, and the number of hits, range and quality.
+// Returns the comment with the extension removed.
+func (p *Packet) parseDFBearing(comment string) string {
+	if len(comment) < 8 || comment[0] != '/' || comment[4] != '/' ||
+		!isDigits(comment[1:4]) || !isDigits(comment[5:8]) {
+		return comment
+	}
+
+	brg, _ := strconv.Atoi(comment[1:4])
+	if brg > 360 {
+		return comment
+	}
+
+	df := p.df()
+	df.Bearing = &brg
+
+	n := int(comment[5] - '0')
+	df.Hits = &n
+
+	// Range and quality are meaningless without hits
+	if n != 0 {
+		rng := math.Pow(2, float64(comment[6]-'0')) * 1.609344 // miles to km
+		df.Range = &rng
+		q := int(comment[7] - '0')
+		df.Quality = &q
+	}
+
+	return comment[8:]
+}
+
+// isDigits reports whether s is non-empty and consists of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,196 @@
+package fap
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestDFReport(t *testing.T) {
+	packet := "N0CALL>APRS:!4903.50N/07201.75W\\088/036/270/729 fox"
+
+	p, err := Parse(packet)
+	if err != nil {
+		t.Fatalf("failed to parse DF report: %v", err)
+	}
+
+	if p.Course == nil || *p.Course != 88 {
+		t.Errorf("course = %v, want 88", p.Course)
+	}
+	df := p.DF
+	if df == nil {
+		t.Fatal("DF is nil")
+	}
+	if df.Bearing == nil || *df.Bearing != 270 {
+		t.Errorf("bearing = %v, want 270", df.Bearing)
+	}
+	if df.Hits == nil || *df.Hits != 7 {
+		t.Errorf("hits = %v, want 7", df.Hits)
+	}
+	if df.Range == nil || math.Abs(*df.Range-4*1.609344) > 0.0001 {
+		t.Errorf("range = %v, want %f", df.Range, 4*1.609344)
+	}
+	if df.Quality == nil || *df.Quality != 9 {
+		t.Errorf("quality = %v, want 9", df.Quality)
+	}
+	if df.Strength != nil {
+		t.Errorf("strength = %d, want nil", *df.Strength)
+	}
+	if p.Comment != "fox" {
+		t.Errorf("comment = %q, want %q", p.Comment, "fox")
+	}
+}
+
+func TestDFReportNoHits(t *testing.T) {
+	p, err := Parse("N0CALL>APRS:!4903.50N/07201.75W\\088/036/270/099")
+	if err != nil {
+		t.Fatalf("failed to parse DF report: %v", err)
+	}
+	if p.DF == nil || p.DF.Hits == nil || *p.DF.Hits != 0 {
+		t.Fatalf("DF = %+v, want 0 hits", p.DF)
+	}
+	if p.DF.Range != nil || p.DF.Quality != nil {
+		t.Errorf("range/quality = %v/%v, want nil", p.DF.Range, p.DF.Quality)
+	}
+}
+
+func TestDFReportNotDF(t *testing.T) {
+	tests := []struct {
+		name        string
+		packet      string
+		wantComment string
+	}{
+		{"not a DF symbol", "N0CALL>APRS:!4903.50N/07201.75W>088/036/270/729", "270/729"},
+		{"bearing out of range", "N0CALL>APRS:!4903.50N/07201.75W\\088/036/361/729", "361/729"},
+		{"not digits", "N0CALL>APRS:!4903.50N/07201.75W\\088/036/27x/729", "27x/729"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Parse(tc.packet)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if p.DF != nil {
+				t.Errorf("DF = %+v, want nil", p.DF)
+			}
+			if p.Comment != tc.wantComment {
+				t.Errorf("comment = %q, want %q", p.Comment, tc.wantComment)
+			}
+		})
+	}
+}
+
+func TestDFS(t *testing.T) {
+	tests := []struct {
+		name            string
+		packet          string
+		wantStrength    int
+		wantHeight      float64
+		wantGain        int
+		wantDirectivity *int
+		wantComment     string
+	}{
+		{"omni", "N0CALL>APRS:!4903.50N/07201.75W\\DFS2360/Fox hunt", 2, 80 * 0.3048, 6, new(0), "Fox hunt"},
+		{"directional", "N0CALL>APRS:!4903.50N/07201.75W\\DFS9932", 9, 5120 * 0.3048, 3, new(90), ""},
+		{"undefined directivity", "N0CALL>APRS:!4903.50N/07201.75W/DFS0009 x", 0, 10 * 0.3048, 0, nil, "x"},
+		{"object", "N0CALL>APRS:;FOX      *092345z4903.50N/07201.75W\\DFS5141", 5, 20 * 0.3048, 4, new(45), ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Parse(tc.packet)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			df := p.DF
+			if df == nil {
+				t.Fatal("DF is nil")
+			}
+			if p.DFS == "" || fmt.Sprint(*df.Strength) != p.DFS[:1] {
+				t.Errorf("DFS = %q", p.DFS)
+			}
+			if *df.Strength != tc.wantStrength {
+				t.Errorf("strength = %d, want %d", *df.Strength, tc.wantStrength)
+			}
+			if math.Abs(*df.Height-tc.wantHeight) > 0.0001 {
+				t.Errorf("height = %f, want %f", *df.Height, tc.wantHeight)
+			}
+			if *df.Gain != tc.wantGain {
+				t.Errorf("gain = %d, want %d", *df.Gain, tc.wantGain)
+			}
+			if !wxIntEqual(df.Directivity, tc.wantDirectivity) {
+				t.Errorf("directivity = %v, want %v", df.Directivity, tc.wantDirectivity)
+			}
+			if df.Bearing != nil {
+				t.Errorf("bearing = %d, want nil", *df.Bearing)
+			}
+			if p.Comment != tc.wantComment {
+				t.Errorf("comment = %q, want %q", p.Comment, tc.wantComment)
+			}
+		})
+	}
+}
+
+func TestDFReportCompressed(t *testing.T) {
+	body, err := EncodeCompressedPosition(49.0583, -72.0292, new(36*1.852), new(88.0), nil, "/\\",
+		&EncodePositionOpts{Comment: "/270/729"})
+	if err != nil {
+		t.Fatalf("EncodeCompressedPosition failed: %v", err)
+	}
+
+	for _, packet := range []string{
+		"N0CALL>APRS:" + body,
+		"N0CALL>APRS:;FOX      *092345z" + body[1:],
+	} {
+		p, err := Parse(packet)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", packet, err)
+		}
+		if p.Format != FormatCompressed {
+			t.Errorf("format = %q, want %q", p.Format, FormatCompressed)
+		}
+		if p.DF == nil || p.DF.Bearing == nil || *p.DF.Bearing != 270 {
+			t.Fatalf("DF = %+v, want bearing 270", p.DF)
+		}
+		if *p.DF.Hits != 7 || *p.DF.Quality != 9 {
+			t.Errorf("hits/quality = %d/%d, want 7/9", *p.DF.Hits, *p.DF.Quality)
+		}
+		if p.Comment != "" {
+			t.Errorf("comment = %q, want empty", p.Comment)
+		}
+	}
+}
+
+func TestDFSCompressed(t *testing.T) {
+	body, err := EncodeCompressedPosition(49.0583, -72.0292, nil, nil, nil, "/\\",
+		&EncodePositionOpts{Comment: "DFS2360 Fox hunt"})
+	if err != nil {
+		t.Fatalf("EncodeCompressedPosition failed: %v", err)
+	}
+
+	for _, packet := range []string{
+		"N0CALL>APRS:" + body,
+		"N0CALL>APRS:;FOX      *092345z" + body[1:],
+	} {
+		p, err := Parse(packet)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", packet, err)
+		}
+		if p.Format != FormatCompressed {
+			t.Errorf("format = %q, want %q", p.Format, FormatCompressed)
+		}
+		if p.DFS != "2360" || p.DF == nil || p.DF.Strength == nil || *p.DF.Strength != 2 {
+			t.Fatalf("DFS = %q, DF = %+v, want strength 2", p.DFS, p.DF)
+		}
+		if math.Abs(*p.DF.Height-80*0.3048) > 0.0001 {
+			t.Errorf("height = %f, want %f", *p.DF.Height, 80*0.3048)
+		}
+		if *p.DF.Gain != 6 || p.DF.Directivity == nil || *p.DF.Directivity != 0 {
+			t.Errorf("gain/directivity = %d/%v, want 6/0", *p.DF.Gain, p.DF.Directivity)
+		}
+		if p.Comment != "Fox hunt" {
+			t.Errorf("comment = %q, want %q", p.Comment, "Fox hunt")
+		}
+	}
+}
@@ -42,6 +42,32 @@ var (
 	ErrTypeNotSupported = &ParseError{Code: "type_not_supported"}
 	ErrExpUnsupported   = &ParseError{Code: "exp_unsupp"}
 
+	// User-defined format errors
+	ErrUserDefInvalid = &ParseError{Code: "user_def_inv"}
+
+	// Query errors
+	ErrQueryInvalid = &ParseError{Code: "query_inv"}
+
+	// KISS and AX.25 frame errors
+	ErrKISSInvalid  = &ParseError{Code: "kiss_inv"}
+	ErrAX25Invalid  = &ParseError{Code: "ax25_inv"}
+	ErrAX25NotUI    = &ParseError{Code: "ax25_not_ui"}
+	ErrAGWPEInvalid = &ParseError{Code: "agwpe_inv"}
+
+	// Audio errors
+	ErrWAVInvalid     = &ParseError{Code: "wav_inv"}
+	ErrAFSKInvalid    = &ParseError{Code: "afsk_inv"}
+	ErrAFSKEncInvalid = &ParseError{Code: "afsk_enc_inv"}
+
+	// AX.25 encoding errors
+	ErrAX25EncInvalid = &ParseError{Code: "ax25_enc_inv"}
+
+	// APRS-IS filter errors
+	ErrFilterInvalid = &ParseError{Code: "filter_inv"}
+
+	// Third-party errors
+	ErrThirdPartyDepth = &ParseError{Code: "tp_depth"}
+
 	// Position errors
 	ErrPosAmbiguity  = &ParseError{Code: "loc_amb_inv"}
 	ErrPosShort      = &ParseError{Code: "pos_short"}
@@ -63,12 +89,19 @@ var (
 	ErrMiceInvDstCall   = &ParseError{Code: "mice_inv_dstcall"}
 	ErrMiceInvInfoField = &ParseError{Code: "mice_inv_infofield"}
 
+	// Mic-E encoding errors
+	ErrMiceEncInvalid = &ParseError{Code: "mice_enc_inv"}
+
 	// Object/item errors
 	ErrObjShort    = &ParseError{Code: "obj_short"}
 	ErrObjInvalid  = &ParseError{Code: "obj_inv"}
 	ErrItemShort   = &ParseError{Code: "item_short"}
 	ErrItemInvalid = &ParseError{Code: "item_inv"}
 
+	// Object/item encoding errors
+	ErrObjEncInvalid  = &ParseError{Code: "obj_enc_inv"}
+	ErrItemEncInvalid = &ParseError{Code: "item_enc_inv"}
+
 	// Message errors
 	ErrMsgShort      = &ParseError{Code: "msg_short"}
 	ErrMsgInvalid    = &ParseError{Code: "msg_inv"}
@@ -93,6 +126,15 @@ var (
 	// Weather errors
 	ErrWxInvalid = &ParseError{Code: "wx_inv"}
 
+	// Status encoding errors
+	ErrStatusEncInvalid = &ParseError{Code: "status_enc_inv"}
+
+	// Weather encoding errors
+	ErrWxEncInvalid = &ParseError{Code: "wx_enc_inv"}
+
 	// Telemetry errors
 	ErrTlmInvalid = &ParseError{Code: "tlm_inv"}
+
+	// Telemetry encoding errors
+	ErrTlmEncInvalid = &ParseError{Code: "tlm_enc_inv"}
 )
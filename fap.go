@@ -14,6 +14,7 @@
 //   - Station capabilities
 //   - NMEA (GPRMC, GPGGA, GPGLL)
 //   - DX spots
+//   - Third-party packets
 package fap
 
 import (
@@ -35,6 +36,9 @@ const (
 	PacketTypeStatus           PacketType = "status"
 	PacketTypeCapabilities     PacketType = "capabilities"
 	PacketTypeBeacon           PacketType = "beacon"
+	PacketTypeThirdParty       PacketType = "third-party"
+	PacketTypeQuery            PacketType = "query"
+	PacketTypeUserDefined      PacketType = "user-defined"
 )
 
 // Format represents the position encoding format used in a packet.
@@ -82,6 +86,22 @@ type Telemetry struct {
 	Bits string     // Digital bits (8-bit string)
 }
 
+// TelemetryDefinition contains telemetry channel definitions, sent by a
+// station in PARM, UNIT, EQNS and BITS messages addressed to itself.
+type TelemetryDefinition struct {
+	Names    []string       // PARM: names of analog channels 1-5, then digital bits 1-8
+	Units    []string       // UNIT: units or labels of analog channels 1-5, then digital bits 1-8
+	Eqns     []TelemetryEqn // EQNS: scaling equations of analog channels 1-5
+	BitSense string         // BITS: 8 characters of '0'/'1', the bit values that are "on"
+	Project  string         // BITS: project title
+}
+
+// TelemetryEqn contains the coefficients of an analog telemetry channel
+// scaling equation: value = A*x^2 + B*x + C.
+type TelemetryEqn struct {
+	A, B, C float64
+}
+
 // Message contains data from an APRS message packet.
 type Message struct {
 	Destination string // Message destination callsign
@@ -91,6 +111,82 @@ type Message struct {
 	RejID       string // Message reject ID
 }
 
+// DirectionFinding contains direction finding data from a DF report
+// (/BRG/NRQ following the course and speed) or a DFS data extension.
+type DirectionFinding struct {
+	Bearing     *int     // BRG: bearing to the signal in degrees
+	Hits        *int     // NRQ: number of hits, 0-8 relative to the period (9 = manual report)
+	Range       *float64 // NRQ: range in km (nil if there were no hits)
+	Quality     *int     // NRQ: 0 = useless, 1-9 = beamwidth below 240/120/64/32/16/8/4/2/1 degrees (nil if there were no hits)
+	Strength    *int     // DFS: signal strength in S-points, 0-9
+	Height      *float64 // DFS: antenna height above average terrain in meters
+	Gain        *int     // DFS: antenna gain in dB
+	Directivity *int     // DFS: antenna directivity in degrees (0 = omnidirectional, nil if undefined)
+}
+
+// AreaShape is the shape of an area object.
+type AreaShape int
+
+const (
+	AreaCircle         AreaShape = 0 // circle
+	AreaLineRight      AreaShape = 1 // line, corridor to the right of the line
+	AreaEllipse        AreaShape = 2 // ellipse
+	AreaTriangle       AreaShape = 3 // triangle
+	AreaBox            AreaShape = 4 // box
+	AreaFilledCircle   AreaShape = 5 // colour-filled circle
+	AreaLineLeft       AreaShape = 6 // line, corridor to the left of the line
+	AreaFilledEllipse  AreaShape = 7 // colour-filled ellipse
+	AreaFilledTriangle AreaShape = 8 // colour-filled triangle
+	AreaFilledBox      AreaShape = 9 // colour-filled box
+)
+
+// Area contains the shape of an area object (symbol \l), drawn from the
+// object position to the position offset by LatOffset and LonOffset.
+type Area struct {
+	Shape     AreaShape // Shape of the area
+	LatOffset float64   // Latitude offset in degrees
+	LonOffset float64   // Longitude offset in degrees
+	Color     int       // Colour: 0-7 low intensity, 8-15 high intensity (black, blue, green, cyan, red, violet, yellow, gray)
+	LineWidth *float64  // Corridor width of line objects in km (nil if not given)
+}
+
+// QueryType is the type of an APRS query.
+type QueryType string
+
+const (
+	// General queries, answered by all stations of the kind queried
+	QueryAPRS  QueryType = "APRS"  // ?APRS?: all stations report their position
+	QueryIGate QueryType = "IGATE" // ?IGATE?: IGates report their capabilities
+	QueryWx    QueryType = "WX"    // ?WX?: weather stations report their weather
+
+	// Directed queries, sent in a message to a single station
+	QueryPosition    QueryType = "APRSP" // ?APRSP: position
+	QueryStatus      QueryType = "APRSS" // ?APRSS: status
+	QueryTrace       QueryType = "APRST" // ?APRST: trace route of the query
+	QueryPing        QueryType = "PING"  // ?PING?: same as ?APRST
+	QueryMessages    QueryType = "APRSM" // ?APRSM: outstanding messages
+	QueryDirectHeard QueryType = "APRSD" // ?APRSD: stations heard directly
+	QueryObjects     QueryType = "APRSO" // ?APRSO: objects
+)
+
+// Query contains data from an APRS query. General queries may be limited
+// to stations within a target footprint.
+type Query struct {
+	Type      QueryType // Query type
+	Directed  bool      // Query was sent in a message to a single station
+	Latitude  *float64  // Footprint centre latitude (nil if no footprint)
+	Longitude *float64  // Footprint centre longitude (nil if no footprint)
+	Radius    *float64  // Footprint radius in km (nil if no footprint)
+}
+
+// UserDefined contains data from a user-defined format packet: {UTdata
+type UserDefined struct {
+	UserID     byte   // User ID, identifying the author of the format
+	PacketType byte   // Packet type within the user's format
+	Payload    string // Raw data following the user ID and packet type
+	Data       any    // Data returned by a registered decoder (nil if no decoder)
+}
+
 // Packet represents a parsed APRS packet.
 type Packet struct {
 	// Always present on successful parse
@@ -127,6 +223,10 @@ type Packet struct {
 	PHG        string   // PHG data string (4 chars, or 5 for PHGRA)
 	RadioRange *float64 // Radio range in km
 
+	// Direction finding
+	DFS string            // DFS data string (4 chars)
+	DF  *DirectionFinding // Direction finding data (nil if not present)
+
 	// Timestamp
 	Timestamp    *time.Time // Timestamp from the packet (when RawTimestamp is false)
 	RawTimestamp string     // Raw timestamp string (when RawTimestamp option is true)
@@ -136,21 +236,38 @@ type Packet struct {
 	ItemName   string // Name of item
 	Alive      *bool  // Object/item alive status
 
+	// Special objects and items
+	Area     *Area  // Area object shape (nil if not an area object)
+	Signpost string // Signpost display text, 1-3 characters
+
 	// Messages
 	Message *Message // Message data (nil if not a message packet)
 
 	// Status
 	Status string // Status text
 
+	// Queries
+	Query *Query // Query data (nil if not a query)
+
+	// User-defined formats
+	UserDefined *UserDefined // User-defined format data (nil if not user-defined)
+
 	// Weather
 	Wx *Weather // Weather data (nil if no weather)
 
 	// Telemetry
-	TelemetryData *Telemetry // Telemetry data
+	TelemetryData *Telemetry           // Telemetry data
+	TelemetryDef  *TelemetryDefinition // Telemetry definition from a PARM, UNIT, EQNS or BITS message
 
 	// Capabilities
 	Capabilities map[string]string // Station capabilities
 
+	// Device identification
+	Device *Device // Sending device (nil if not identified or identification not enabled)
+
+	// Third-party
+	ThirdParty *Packet // Encapsulated packet of a third-party packet (nil if not third-party)
+
 	// Mic-E specifics
 	MBits       string // Mic-E message bits
 	MiceMangled bool   // True if mic-e packet was repaired
@@ -176,6 +293,9 @@ type options struct {
 	isAX25           bool
 	acceptBrokenMicE bool
 	rawTimestamp     bool
+	depth            int // third-party nesting level
+	userDecoders     map[[2]byte]UserDefinedDecoder
+	devices          *DeviceTable // device identification table (nil = disabled)
 }
 
 // Option configures parsing behavior.
@@ -196,6 +316,31 @@ func WithRawTimestamp() Option {
 	return func(o *options) { o.rawTimestamp = true }
 }
 
+// WithUserDefinedDecoder registers a decoder for the user-defined format
+// with the given user ID and packet type ({UT...). The option may be given
+// several times to register decoders for different formats.
+func WithUserDefinedDecoder(userID, packetType byte, dec UserDefinedDecoder) Option {
+	return func(o *options) {
+		if o.userDecoders == nil {
+			o.userDecoders = make(map[[2]byte]UserDefinedDecoder)
+		}
+		o.userDecoders[[2]byte{userID, packetType}] = dec
+	}
+}
+
+// WithDeviceID identifies the sending device using the embedded device
+// table. The device is stored in Packet.Device, and the device markers are
+// removed from the comment of Mic-E packets.
+func WithDeviceID() Option {
+	return func(o *options) { o.devices = DefaultDeviceTable() }
+}
+
+// WithDeviceTable identifies the sending device like WithDeviceID, using
+// the given device table.
+func WithDeviceTable(t *DeviceTable) Option {
+	return func(o *options) { o.devices = t }
+}
+
 // Parse parses an APRS packet in TNC2 / APRS-IS text format.
 // It returns a Packet struct with all parsed fields populated.
 // On failure, the returned error is a *ParseError with Code and Msg fields.
@@ -205,6 +350,12 @@ func Parse(raw string, opts ...Option) (*Packet, error) {
 		o(&opt)
 	}
 
+	return parse(raw, &opt)
+}
+
+// parse parses a packet with the given options. It is called recursively
+// for the packets encapsulated in third-party packets.
+func parse(raw string, opt *options) (*Packet, error) {
 	p := &Packet{
 		OrigPacket: raw,
 	}
@@ -219,15 +370,19 @@ func Parse(raw string, opts ...Option) (*Packet, error) {
 	p.Body = raw[colonIdx+1:]
 
 	// Parse header: SRC>DST,DIGI1,DIGI2,...
-	if err := p.parseHeader(&opt); err != nil {
+	if err := p.parseHeader(opt); err != nil {
 		return p, err
 	}
 
 	// Determine packet type from the first character(s) of the body
-	if err := p.parseBody(&opt); err != nil {
+	if err := p.parseBody(opt); err != nil {
 		return p, err
 	}
 
+	if opt.devices != nil {
+		p.identifyDevice(opt.devices)
+	}
+
 	return p, nil
 }
 
@@ -372,6 +527,15 @@ func (p *Packet) parseBody(opt *options) error {
 	case '<':
 		// Capabilities
 		return p.parseCapabilities(opt)
+	case '}':
+		// Third-party
+		return p.parseThirdParty(opt)
+	case '?':
+		// General query
+		if p.parseQuery() {
+			return nil
+		}
+		return p.parsePositionOrBeacon(opt)
 	case '_':
 		// Positionless weather
 		return p.parseWeatherPositionless(opt)
@@ -388,9 +552,9 @@ func (p *Packet) parseBody(opt *options) error {
 		}
 		return p.parsePositionOrBeacon(opt)
 	case '{':
-		// Experimental
-		if len(p.Body) > 1 && p.Body[1] == '{' {
-			return p.fail(ErrExpUnsupported, "unsupported experimental packet")
+		// User-defined, including the experimental {{ formats
+		if len(p.Body) >= 3 {
+			return p.parseUserDefined(opt)
 		}
 		return p.parsePositionOrBeacon(opt)
 	default:
@@ -0,0 +1,572 @@
+package fap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Filter types of the APRS-IS server filter language, as implemented by
+// aprsc, and the letters used in their arguments.
+const (
+	filterTypes    = "rpbotsdaegqmfu"
+	filterPktTypes = "poimqstunwc" // packet types of the t/ filter
+	filterQConstrs = "CXUoOSrRZI"  // q construct letters of the q/ filter
+)
+
+// Filter is a parsed APRS-IS server filter, such as
+// "r/60.18/24.94/100 b/N0CALL* -t/w", which can be applied to packets
+// locally. A packet matches if it matches at least one of the terms and
+// none of the exclusion terms prefixed with '-'.
+//
+// The m/, f/ and t/ filters with a station are relative to the last known
+// position of a station. Match tracks the positions of these stations from
+// the packets it is given, as does a server. The q/ filter with the I
+// analysis tracks the igates seen in the q constructs. A Filter can be
+// used from several goroutines.
+type Filter struct {
+	raw      string
+	terms    []*filterTerm
+	excludes []*filterTerm
+	callsign string // own callsign, for m/
+
+	mu        sync.Mutex
+	tracked   map[string]*filterPos // positions of the stations used by the terms
+	igates    map[string]bool       // igates identified by qAr, qAo and qAR
+	useIGates bool                  // igates need to be tracked
+}
+
+// filterPos is a tracked station position.
+type filterPos struct {
+	lat, lon float64
+}
+
+// filterTerm is a parsed filter term. The fields used depend on the type.
+type filterTerm struct {
+	raw     string
+	typ     byte     // filter type, lower case
+	calls   []string // p/ prefixes; b/ o/ d/ e/ g/ u/ patterns; f/ t/ station
+	lat     float64  // r/ centre; a/ north edge
+	lon     float64  // r/ centre; a/ west edge
+	latS    float64  // a/ south edge
+	lonE    float64  // a/ east edge
+	dist    float64  // r/ m/ f/ t/ range in km
+	types   string   // t/ packet types
+	symbols [3]string
+	qcons   string // q/ q construct letters
+	qIGates bool   // q/ I analysis
+}
+
+// ParseFilter parses an APRS-IS server filter. callsign is the station's
+// own callsign, which is the centre of the m/ filter; the m/ filter does
+// not match anything if it is empty. Invalid filters return a *ParseError
+// naming the term and the argument at fault.
+func ParseFilter(filter, callsign string) (*Filter, error) {
+	if strings.ContainsAny(filter, "\r\n") {
+		return nil, &ParseError{Code: ErrFilterInvalid.Code, Msg: "line break in filter"}
+	}
+	fields := strings.Fields(filter)
+	if len(fields) == 0 {
+		return nil, &ParseError{Code: ErrFilterInvalid.Code, Msg: "empty filter"}
+	}
+
+	f := &Filter{
+		raw:      strings.Join(fields, " "),
+		callsign: strings.ToUpper(callsign),
+		tracked:  make(map[string]*filterPos),
+		igates:   make(map[string]bool),
+	}
+	if f.callsign != "" {
+		f.tracked[f.callsign] = nil
+	}
+
+	for _, field := range fields {
+		t, err := parseFilterTerm(field)
+		if err != nil {
+			return nil, err
+		}
+		switch t.typ {
+		case 'f', 't':
+			if len(t.calls) > 0 {
+				f.tracked[t.calls[0]] = nil
+			}
+		case 'q':
+			f.useIGates = f.useIGates || t.qIGates
+		}
+		if strings.HasPrefix(field, "-") {
+			f.excludes = append(f.excludes, t)
+		} else {
+			f.terms = append(f.terms, t)
+		}
+	}
+
+	return f, nil
+}
+
+// ValidateFilter checks that a filter string is a valid APRS-IS server
+// filter, such as "r/60.18/24.94/100 -t/c", before it is sent to a server.
+func ValidateFilter(filter string) error {
+	_, err := ParseFilter(filter, "")
+	return err
+}
+
+// filterError returns an error about a filter term.
+func filterError(term, format string, args ...any) error {
+	return &ParseError{Code: ErrFilterInvalid.Code, Msg: fmt.Sprintf("%q: ", term) + fmt.Sprintf(format, args...)}
+}
+
+// parseFilterTerm parses a filter term, with an optional '-' prefix.
+func parseFilterTerm(raw string) (*filterTerm, error) {
+	typ, rest, ok := strings.Cut(strings.TrimPrefix(raw, "-"), "/")
+	if !ok || len(typ) != 1 || !strings.Contains(filterTypes, strings.ToLower(typ)) {
+		return nil, filterError(raw, "unknown filter type")
+	}
+	if rest == "" {
+		return nil, filterError(raw, "no arguments")
+	}
+
+	t := &filterTerm{raw: raw, typ: strings.ToLower(typ)[0]}
+	args := strings.Split(rest, "/")
+
+	// count checks the number of arguments
+	count := func(lo, hi int) error {
+		if len(args) < lo || len(args) > hi {
+			if lo == hi {
+				return filterError(raw, "%d arguments, want %d", len(args), lo)
+			}
+			return filterError(raw, "%d arguments, want %d to %d", len(args), lo, hi)
+		}
+		return nil
+	}
+
+	var err error
+	switch t.typ {
+	case 'r':
+		if err := count(3, 3); err != nil {
+			return nil, err
+		}
+		if t.lat, err = filterCoord(raw, "latitude", args[0], 90); err != nil {
+			return nil, err
+		}
+		if t.lon, err = filterCoord(raw, "longitude", args[1], 180); err != nil {
+			return nil, err
+		}
+		if t.dist, err = filterDist(raw, args[2]); err != nil {
+			return nil, err
+		}
+
+	case 'a':
+		if err := count(4, 4); err != nil {
+			return nil, err
+		}
+		if t.lat, err = filterCoord(raw, "north latitude", args[0], 90); err != nil {
+			return nil, err
+		}
+		if t.lon, err = filterCoord(raw, "west longitude", args[1], 180); err != nil {
+			return nil, err
+		}
+		if t.latS, err = filterCoord(raw, "south latitude", args[2], 90); err != nil {
+			return nil, err
+		}
+		if t.lonE, err = filterCoord(raw, "east longitude", args[3], 180); err != nil {
+			return nil, err
+		}
+		if t.latS > t.lat {
+			return nil, filterError(raw, "south latitude %s is north of north latitude %s", args[2], args[0])
+		}
+		if t.lonE < t.lon {
+			return nil, filterError(raw, "east longitude %s is west of west longitude %s", args[3], args[1])
+		}
+
+	case 'p', 'b', 'o', 'd', 'e', 'g', 'u':
+		for i, a := range args {
+			if a == "" {
+				return nil, filterError(raw, "empty argument %d", i+1)
+			}
+			if t.typ == 'p' && strings.ContainsAny(a, "*?") {
+				return nil, filterError(raw, "wildcard in prefix %q", a)
+			}
+			if t.typ != 'o' {
+				a = strings.ToUpper(a)
+			}
+			t.calls = append(t.calls, a)
+		}
+
+	case 't':
+		if err := count(1, 3); err != nil {
+			return nil, err
+		}
+		if args[0] == "" {
+			return nil, filterError(raw, "no packet types")
+		}
+		for _, c := range args[0] {
+			if !strings.ContainsRune(filterPktTypes, c) {
+				return nil, filterError(raw, "unknown packet type %q, want one of %q", c, filterPktTypes)
+			}
+		}
+		t.types = args[0]
+		if len(args) > 1 {
+			if len(args) != 3 || args[1] == "" {
+				return nil, filterError(raw, "a station needs a range: t/types/call/km")
+			}
+			t.calls = []string{strings.ToUpper(args[1])}
+			if t.dist, err = filterDist(raw, args[2]); err != nil {
+				return nil, err
+			}
+		}
+
+	case 's':
+		if err := count(1, 3); err != nil {
+			return nil, err
+		}
+		copy(t.symbols[:], args)
+		if t.symbols[0] == "" && t.symbols[1] == "" {
+			return nil, filterError(raw, "no symbols")
+		}
+
+	case 'q':
+		if err := count(1, 2); err != nil {
+			return nil, err
+		}
+		for _, c := range args[0] {
+			if !strings.ContainsRune(filterQConstrs, c) {
+				return nil, filterError(raw, "unknown q construct %q, want one of %q", c, filterQConstrs)
+			}
+		}
+		t.qcons = args[0]
+		if len(args) == 2 {
+			// I passes the positions of igates
+			if args[1] != "I" {
+				return nil, filterError(raw, "unknown analysis %q, want \"I\"", args[1])
+			}
+			t.qIGates = true
+		}
+		if t.qcons == "" && !t.qIGates {
+			return nil, filterError(raw, "no q constructs")
+		}
+
+	case 'm':
+		if err := count(1, 1); err != nil {
+			return nil, err
+		}
+		if t.dist, err = filterDist(raw, args[0]); err != nil {
+			return nil, err
+		}
+
+	case 'f':
+		if err := count(2, 2); err != nil {
+			return nil, err
+		}
+		if args[0] == "" {
+			return nil, filterError(raw, "no station")
+		}
+		t.calls = []string{strings.ToUpper(args[0])}
+		if t.dist, err = filterDist(raw, args[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// filterCoord parses a latitude or longitude in decimal degrees.
+func filterCoord(term, name, s string, limit float64) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, filterError(term, "invalid %s %q", name, s)
+	}
+	if v < -limit || v > limit {
+		return 0, filterError(term, "%s %s out of range", name, s)
+	}
+	return v, nil
+}
+
+// filterDist parses a range in km.
+func filterDist(term, s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, filterError(term, "invalid range %q", s)
+	}
+	if v <= 0 {
+		return 0, filterError(term, "range %s is not positive", s)
+	}
+	return v, nil
+}
+
+// String returns the filter, with the terms separated by single spaces.
+func (f *Filter) String() string {
+	return f.raw
+}
+
+// Match reports whether a packet passes the filter, after updating the
+// tracked station positions and igates from it.
+func (f *Filter) Match(p *Packet) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.track(p)
+
+	matched := false
+	for _, t := range f.terms {
+		if f.matchTerm(t, p) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	for _, t := range f.excludes {
+		if f.matchTerm(t, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// track updates the tracked state from a packet.
+func (f *Filter) track(p *Packet) {
+	if pos, ok := f.tracked[strings.ToUpper(p.SrcCallsign)]; ok && filterHasPos(p) && p.ObjectName == "" && p.ItemName == "" {
+		if pos == nil {
+			pos = &filterPos{}
+			f.tracked[strings.ToUpper(p.SrcCallsign)] = pos
+		}
+		pos.lat, pos.lon = *p.Latitude, *p.Longitude
+	}
+
+	if f.useIGates {
+		if q, entry := filterQConstruct(p); q == "qAr" || q == "qAo" || q == "qAR" {
+			f.igates[strings.ToUpper(entry)] = true
+		}
+	}
+}
+
+// matchTerm reports whether a packet matches a term.
+func (f *Filter) matchTerm(t *filterTerm, p *Packet) bool {
+	switch t.typ {
+	case 'r':
+		return filterInRange(p, t.lat, t.lon, t.dist)
+
+	case 'a':
+		return filterHasPos(p) &&
+			*p.Latitude <= t.lat && *p.Latitude >= t.latS &&
+			*p.Longitude >= t.lon && *p.Longitude <= t.lonE
+
+	case 'p':
+		src := strings.ToUpper(p.SrcCallsign)
+		for _, prefix := range t.calls {
+			if strings.HasPrefix(src, prefix) {
+				return true
+			}
+		}
+
+	case 'b':
+		return filterGlobAny(t.calls, strings.ToUpper(p.SrcCallsign))
+
+	case 'o':
+		name := strings.TrimRight(p.ObjectName, " ")
+		if name == "" {
+			name = p.ItemName
+		}
+		return name != "" && filterGlobAny(t.calls, name)
+
+	case 'd':
+		// Only the last digipeater which relayed the packet is marked with
+		// '*', the ones before it have relayed it too
+		last := -1
+		for i, d := range p.Digipeaters {
+			if d.WasDigied {
+				last = i
+			}
+		}
+		for _, d := range p.Digipeaters[:last+1] {
+			if filterGlobAny(t.calls, strings.ToUpper(d.Call)) {
+				return true
+			}
+		}
+
+	case 'e':
+		_, entry := filterQConstruct(p)
+		return entry != "" && filterGlobAny(t.calls, strings.ToUpper(entry))
+
+	case 'g':
+		return p.Message != nil && filterGlobAny(t.calls, strings.ToUpper(strings.TrimSpace(p.Message.Destination)))
+
+	case 'u':
+		return filterGlobAny(t.calls, strings.ToUpper(p.DstCallsign))
+
+	case 't':
+		if !filterMatchTypes(t.types, p) {
+			return false
+		}
+		if len(t.calls) == 0 {
+			return true
+		}
+		pos := f.tracked[t.calls[0]]
+		return pos != nil && filterInRange(p, pos.lat, pos.lon, t.dist)
+
+	case 's':
+		return filterMatchSymbol(t.symbols, p)
+
+	case 'q':
+		q, _ := filterQConstruct(p)
+		if q != "" && t.qcons != "" && strings.Contains(t.qcons, q[2:]) {
+			return true
+		}
+		return t.qIGates && filterHasPos(p) && f.igates[strings.ToUpper(p.SrcCallsign)]
+
+	case 'm':
+		pos := f.tracked[f.callsign]
+		return f.callsign != "" && pos != nil && filterInRange(p, pos.lat, pos.lon, t.dist)
+
+	case 'f':
+		pos := f.tracked[t.calls[0]]
+		return pos != nil && filterInRange(p, pos.lat, pos.lon, t.dist)
+	}
+
+	return false
+}
+
+// filterHasPos reports whether a packet has a position.
+func filterHasPos(p *Packet) bool {
+	return p.Latitude != nil && p.Longitude != nil
+}
+
+// filterInRange reports whether a packet has a position within dist km of
+// a point.
+func filterInRange(p *Packet, lat, lon, dist float64) bool {
+	return filterHasPos(p) && Distance(lat, lon, *p.Latitude, *p.Longitude) <= dist
+}
+
+// filterQConstruct returns the q construct in the path of a packet, such as
+// "qAR", and the call following it, which is the entry station.
+func filterQConstruct(p *Packet) (q, entry string) {
+	for i, d := range p.Digipeaters {
+		if len(d.Call) == 3 && strings.HasPrefix(d.Call, "qA") {
+			if i+1 < len(p.Digipeaters) {
+				entry = p.Digipeaters[i+1].Call
+			}
+			return d.Call, entry
+		}
+	}
+	return "", ""
+}
+
+// filterMatchTypes reports whether a packet is one of the t/ filter types.
+func filterMatchTypes(types string, p *Packet) bool {
+	for _, c := range types {
+		var match bool
+		switch c {
+		case 'p':
+			match = p.Type == PacketTypeLocation
+		case 'o':
+			match = p.Type == PacketTypeObject
+		case 'i':
+			match = p.Type == PacketTypeItem
+		case 'm':
+			match = p.Type == PacketTypeMessage || p.Type == PacketTypeTelemetryMessage
+		case 'q':
+			match = p.Type == PacketTypeQuery
+		case 's':
+			match = p.Type == PacketTypeStatus
+		case 't':
+			match = p.Type == PacketTypeTelemetry || p.Type == PacketTypeTelemetryMessage
+		case 'u':
+			match = p.Type == PacketTypeUserDefined
+		case 'n':
+			match = p.Message != nil && filterNWSMessage(p.Message.Destination)
+		case 'w':
+			match = p.Type == PacketTypeWx || p.Wx != nil
+		case 'c':
+			match = filterCWOP(p.SrcCallsign)
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// filterNWSMessage reports whether a message destination is a weather
+// bulletin of the National Weather Service format.
+func filterNWSMessage(dst string) bool {
+	for _, prefix := range []string{"NWS-", "SKY", "CWA", "BOM"} {
+		if strings.HasPrefix(dst, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterCWOP reports whether a callsign is one of the Citizen Weather
+// Observer Program, such as CW1234, DW or EW followed by digits.
+func filterCWOP(call string) bool {
+	if len(call) < 3 || call[1] != 'W' || call[0] < 'C' || call[0] > 'F' {
+		return false
+	}
+	for _, c := range []byte(call[2:]) {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// filterMatchSymbol reports whether the symbol of a packet matches an s/
+// filter: a primary table symbol listed in the first argument, or an
+// alternate table symbol listed in the second. If overlays are listed in
+// the third argument, alternate table symbols must have one of them.
+func filterMatchSymbol(symbols [3]string, p *Packet) bool {
+	if p.SymbolCode == 0 {
+		return false
+	}
+	if p.SymbolTable == '/' {
+		return strings.IndexByte(symbols[0], p.SymbolCode) >= 0
+	}
+	if strings.IndexByte(symbols[1], p.SymbolCode) < 0 {
+		return false
+	}
+	return symbols[2] == "" || strings.IndexByte(symbols[2], p.SymbolTable) >= 0
+}
+
+// filterGlobAny reports whether s matches any of the patterns.
+func filterGlobAny(patterns []string, s string) bool {
+	for _, pattern := range patterns {
+		if filterGlob(pattern, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterGlob matches s against a pattern in which '*' matches any string
+// and '?' any single character.
+func filterGlob(pattern, s string) bool {
+	for pattern != "" {
+		switch pattern[0] {
+		case '*':
+			pattern = strings.TrimLeft(pattern, "*")
+			if pattern == "" {
+				return true
+			}
+			for i := range len(s) + 1 {
+				if filterGlob(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+		default:
+			if s == "" || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		s = s[1:]
+	}
+	return s == ""
+}
@@ -0,0 +1,265 @@
+package fap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		valid  bool
+	}{
+		{"r/60.18/24.94/100", true},
+		{"r/60.18/24.94/100 -t/c", true},
+		{"p/OH/N0 b/N0CALL*", true},
+		{"  m/50  ", true},
+		{"T/poimqstunw", true},
+		{"", false},
+		{"   ", false},
+		{"x/1", false},
+		{"r", false},
+		{"r/", false},
+		{"rr/1", false},
+		{"-", false},
+		{"r/60/24/100\r\n#filter p/N0", false},
+	}
+	for _, tc := range tests {
+		err := ValidateFilter(tc.filter)
+		if tc.valid && err != nil {
+			t.Errorf("ValidateFilter(%q) error: %v", tc.filter, err)
+		}
+		if !tc.valid && !errors.Is(err, ErrFilterInvalid) {
+			t.Errorf("ValidateFilter(%q) error = %v, want %v", tc.filter, err, ErrFilterInvalid)
+		}
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []struct {
+		filter string
+		msg    string // expected error message
+	}{
+		{"r/60.18/24.94", `"r/60.18/24.94": 2 arguments, want 3`},
+		{"r/91/24.94/100", `"r/91/24.94/100": latitude 91 out of range`},
+		{"r/60.18/east/100", `"r/60.18/east/100": invalid longitude "east"`},
+		{"r/60.18/24.94/-5", `"r/60.18/24.94/-5": range -5 is not positive`},
+		{"a/60/25/61/26", `"a/60/25/61/26": south latitude 61 is north of north latitude 60`},
+		{"a/61/26/60/25", `"a/61/26/60/25": east longitude 25 is west of west longitude 26`},
+		{"a/61/25/60", `"a/61/25/60": 3 arguments, want 4`},
+		{"b/N0CALL//OH7LZB", `"b/N0CALL//OH7LZB": empty argument 2`},
+		{"p/OH*", `"p/OH*": wildcard in prefix "OH*"`},
+		{"t/px", `"t/px": unknown packet type 'x', want one of "poimqstunwc"`},
+		{"t/p/OH7LZB", `"t/p/OH7LZB": a station needs a range: t/types/call/km`},
+		{"t//OH7LZB/50", `"t//OH7LZB/50": no packet types`},
+		{"s///S", `"s///S": no symbols`},
+		{"q/rA", `"q/rA": unknown q construct 'A', want one of "CXUoOSrRZI"`},
+		{"q/C/X", `"q/C/X": unknown analysis "X", want "I"`},
+		{"m/50/60", `"m/50/60": 2 arguments, want 1`},
+		{"f/OH7LZB", `"f/OH7LZB": 1 arguments, want 2`},
+		{"p/OH -z/1", `"-z/1": unknown filter type`},
+	}
+	for _, tc := range tests {
+		_, err := ParseFilter(tc.filter, "N0CALL")
+		if !errors.Is(err, ErrFilterInvalid) {
+			t.Errorf("ParseFilter(%q) error = %v, want %v", tc.filter, err, ErrFilterInvalid)
+			continue
+		}
+		if got := strings.TrimPrefix(err.Error(), "fap: filter_inv: "); got != tc.msg {
+			t.Errorf("ParseFilter(%q) error = %q, want %q", tc.filter, got, tc.msg)
+		}
+	}
+}
+
+// Test packets for the filter, around Helsinki (60.17N 24.94E)
+var filterTestPackets = map[string]string{
+	"position": "OH7LZB-9>APRS,OH2RDP*,WIDE2-1,qAR,OH2IGATE:!6010.00N/02456.00E>mobile",
+	"tampere":  "OH3ABC>APRS,TCPIP*,qAC,T2FINLAND:!6130.00N/02345.00E-home",
+	"object":   "OH2XYZ>APRS,TCPIP*,qAC,T2FINLAND:;LEADER   *092345z6010.00N/02456.00E>",
+	"item":     "OH2XYZ>APRS,TCPIP*,qAC,T2FINLAND:)AID #2!6010.00N/02456.00EA",
+	"message":  "N0CALL>APRS,TCPIP*,qAC,T2TEST::OH7LZB-9 :Hello{1",
+	"bulletin": "N0CALL>APRS,TCPIP*,qAC,T2TEST::NWS-WARN :Storm warning",
+	"status":   "N0CALL>APDR16,TCPIP*,qAS,N0CALL:>status",
+	"cwop":     "CW1234>APRS,TCPIP*,qAC,T2TEST:_10090556c220s004g005t077r000p000P000h50b09900",
+	"wxpos":    "OH2W>APRS,qAo,OH2IGATE:!6010.00N/02456.00E_220/004g005t077",
+	"overlay":  "OH2DIG>APRS,qAR,OH2IGATE:!6010.00NS02456.00E#",
+	"query":    "N0CALL>APRS,TCPIP*,qAC,T2TEST:?APRS?",
+}
+
+func filterTestPacket(t *testing.T, name string) *Packet {
+	t.Helper()
+	p, err := Parse(filterTestPackets[name])
+	if err != nil {
+		t.Fatalf("Parse(%s) error: %v", name, err)
+	}
+	return p
+}
+
+func TestFilterMatch(t *testing.T) {
+	tests := []struct {
+		filter string
+		match  []string // names of the matching test packets
+	}{
+		{"r/60.18/24.94/10", []string{"position", "object", "item", "wxpos", "overlay"}},
+		{"r/61.5/23.75/10", []string{"tampere"}},
+		{"a/60.2/24.9/60.1/25.0", []string{"position", "object", "item", "wxpos", "overlay"}},
+		{"p/OH7/CW", []string{"position", "cwop"}},
+		{"p/oh7", []string{"position"}},
+		{"b/N0CALL", []string{"message", "bulletin", "status", "query"}},
+		{"b/OH?W b/OH7LZB-*", []string{"position", "wxpos"}},
+		{"o/LEADER o/AID*", []string{"object", "item"}},
+		{"d/OH2RDP", []string{"position"}},
+		{"e/OH2IGATE", []string{"position", "wxpos", "overlay"}},
+		{"g/OH7LZB*", []string{"message"}},
+		{"u/APDR*", []string{"status"}},
+		{"t/o", []string{"object"}},
+		{"t/i", []string{"item"}},
+		{"t/m", []string{"message", "bulletin"}},
+		{"t/n", []string{"bulletin"}},
+		{"t/s", []string{"status"}},
+		{"t/q", []string{"query"}},
+		{"t/w", []string{"cwop", "wxpos"}},
+		{"t/c", []string{"cwop"}},
+		{"s/>", []string{"position", "object"}},
+		{"s//#", []string{"overlay"}},
+		{"s//#/X", nil},
+		{"q/oS", []string{"status", "wxpos"}},
+		{"r/60.18/24.94/10 -t/o -p/OH2DIG", []string{"position", "item", "wxpos"}},
+		{"-t/p", nil},
+	}
+
+	for _, tc := range tests {
+		f, err := ParseFilter(tc.filter, "N0CALL")
+		if err != nil {
+			t.Fatalf("ParseFilter(%q) error: %v", tc.filter, err)
+		}
+		for name := range filterTestPackets {
+			want := false
+			for _, m := range tc.match {
+				want = want || m == name
+			}
+			if got := f.Match(filterTestPacket(t, name)); got != want {
+				t.Errorf("filter %q on %s packet = %v, want %v", tc.filter, name, got, want)
+			}
+		}
+	}
+}
+
+func TestFilterDigipeaters(t *testing.T) {
+	p, err := Parse("OH7LZB-9>APRS,OH7RDA,OH2RDP*,WIDE2,qAR,OH2IGATE:!6010.00N/02456.00E>mobile")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"d/OH7RDA", true}, // relayed before the last digipeater marked with '*'
+		{"d/OH2RDP", true},
+		{"d/OH7R*", true},
+		{"d/WIDE2", false}, // not used yet
+		{"d/OH2IGATE", false},
+	}
+
+	for _, tc := range tests {
+		f, err := ParseFilter(tc.filter, "")
+		if err != nil {
+			t.Fatalf("ParseFilter(%q) error: %v", tc.filter, err)
+		}
+		if got := f.Match(p); got != tc.want {
+			t.Errorf("filter %q = %v, want %v", tc.filter, got, tc.want)
+		}
+	}
+}
+
+func TestFilterTracking(t *testing.T) {
+	f, err := ParseFilter("m/10 f/OH3ABC/20 t/m/OH7LZB-9/5", "OH7LZB-9")
+	if err != nil {
+		t.Fatalf("ParseFilter error: %v", err)
+	}
+	if got := f.String(); got != "m/10 f/OH3ABC/20 t/m/OH7LZB-9/5" {
+		t.Errorf("String = %q", got)
+	}
+
+	// Nothing matches before the positions of the stations are known
+	if f.Match(filterTestPacket(t, "object")) {
+		t.Errorf("object matched before positions are known")
+	}
+
+	// The own position is within range of itself, and then the object
+	if !f.Match(filterTestPacket(t, "position")) {
+		t.Errorf("own position did not match")
+	}
+	if !f.Match(filterTestPacket(t, "object")) {
+		t.Errorf("object near own position did not match")
+	}
+
+	// Positions of objects do not move the station which sent them
+	obj, err := Parse("OH7LZB-9>APRS:;FAR      *092345z6130.00N/02345.00E>")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if f.Match(obj) {
+		t.Errorf("distant object matched")
+	}
+	if !f.Match(filterTestPacket(t, "item")) {
+		t.Errorf("item near own position did not match after distant object")
+	}
+
+	// The friend's position is tracked from their own packet
+	if !f.Match(filterTestPacket(t, "tampere")) {
+		t.Errorf("friend position did not match")
+	}
+	near, err := Parse("OH3XYZ>APRS:!6131.00N/02345.00E-")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if !f.Match(near) {
+		t.Errorf("position near friend did not match")
+	}
+}
+
+func TestFilterQIGates(t *testing.T) {
+	f, err := ParseFilter("q//I", "")
+	if err != nil {
+		t.Fatalf("ParseFilter error: %v", err)
+	}
+
+	igate, err := Parse("OH2IGATE>APRS,TCPIP*,qAC,T2FINLAND:!6010.00N/02456.00E&")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if f.Match(igate) {
+		t.Errorf("igate matched before it was seen gating")
+	}
+	if f.Match(filterTestPacket(t, "position")) {
+		t.Errorf("gated packet matched")
+	}
+	if !f.Match(igate) {
+		t.Errorf("igate position did not match after it was seen gating")
+	}
+}
+
+func TestFilterGlob(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"OH7LZB", "OH7LZB", true},
+		{"OH7LZB", "OH7LZB-9", false},
+		{"OH7LZB*", "OH7LZB-9", true},
+		{"OH7LZB*", "OH7LZB", true},
+		{"*-9", "OH7LZB-9", true},
+		{"OH?LZB", "OH7LZB", true},
+		{"OH?LZB", "OHLZB", false},
+		{"O*Z*", "OH7LZB", true},
+		{"*", "", true},
+	}
+	for _, tc := range tests {
+		if got := filterGlob(tc.pattern, tc.s); got != tc.want {
+			t.Errorf("filterGlob(%q, %q) = %v, want %v", tc.pattern, tc.s, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package fap
+
+// HDLC framing constants.
+const (
+	hdlcFlag     = 0x7E                  // Frame delimiter
+	hdlcMinFrame = 2*ax25AddrLen + 1 + 2 // Addresses, control field and FCS
+	hdlcMaxFrame = 2048                  // Largest frame accepted, including FCS
+)
+
+// fcs16 computes the AX.25 frame check sequence, the CRC-16 used by
+// X.25 and HDLC (CRC-16/X-25).
+func fcs16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for range 8 {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0x8408
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return ^crc
+}
+
+// hdlcBits returns the bits of a frame as transmitted, before NRZI
+// encoding: the preamble flags, the frame and its FCS LSB first with a
+// zero bit stuffed after every five consecutive ones, and the trailing
+// flags.
+func hdlcBits(frame []byte, preamble, postamble int) []bool {
+	bits := make([]bool, 0, (preamble+postamble)*8+(len(frame)+2)*10)
+	flag := func() {
+		for i := range 8 {
+			bits = append(bits, hdlcFlag>>i&1 != 0)
+		}
+	}
+
+	for range preamble {
+		flag()
+	}
+
+	fcs := fcs16(frame)
+	ones := 0
+	for _, b := range append(frame[:len(frame):len(frame)], byte(fcs), byte(fcs>>8)) {
+		for i := range 8 {
+			bit := b>>i&1 != 0
+			bits = append(bits, bit)
+			if !bit {
+				ones = 0
+				continue
+			}
+			ones++
+			if ones == 5 {
+				bits = append(bits, false)
+				ones = 0
+			}
+		}
+	}
+
+	for range postamble {
+		flag()
+	}
+	return bits
+}
+
+// HDLCDeframer extracts AX.25 frames from a stream of received bits, after
+// NRZI decoding. It finds the flags delimiting the frames, removes the
+// stuffed zero bits and verifies the FCS of each frame.
+type HDLCDeframer struct {
+	ones    int    // number of consecutive one bits
+	inFrame bool   // a flag has been seen
+	octet   byte   // octet being assembled, LSB first
+	nbits   int    // number of bits in octet
+	frame   []byte // octets of the frame being received
+}
+
+// Bit adds a received bit. When the bit completes a frame with a valid
+// FCS, the frame is returned without the FCS. Otherwise Bit returns nil.
+func (d *HDLCDeframer) Bit(bit bool) []byte {
+	if bit {
+		d.ones++
+		if d.ones > 6 {
+			// Seven or more ones abort the frame
+			d.reset(false)
+			return nil
+		}
+		d.addBit(1)
+		return nil
+	}
+
+	ones := d.ones
+	d.ones = 0
+
+	switch {
+	case ones == 5:
+		// Stuffed zero bit after five ones
+		return nil
+	case ones == 6:
+		// Flag: the six ones of the flag have been added as data bits,
+		// the rest of the flag is the zero bit before them
+		frame := d.complete()
+		d.reset(true)
+		return frame
+	}
+
+	d.addBit(0)
+	return nil
+}
+
+// addBit adds a data bit to the frame being received.
+func (d *HDLCDeframer) addBit(bit byte) {
+	if !d.inFrame {
+		return
+	}
+	d.octet |= bit << d.nbits
+	d.nbits++
+	if d.nbits == 8 {
+		if len(d.frame) >= hdlcMaxFrame {
+			d.reset(false)
+			return
+		}
+		d.frame = append(d.frame, d.octet)
+		d.octet = 0
+		d.nbits = 0
+	}
+}
+
+// complete returns the frame ended by a flag, without the FCS, if its FCS
+// is valid.
+func (d *HDLCDeframer) complete() []byte {
+	if !d.inFrame {
+		return nil
+	}
+	// The flag's leading zero and six ones: 7 bits, which were added to
+	// the octet being assembled before the flag was recognized
+	if d.nbits != 7 || len(d.frame) < hdlcMinFrame {
+		return nil
+	}
+
+	n := len(d.frame) - 2
+	fcs := uint16(d.frame[n]) | uint16(d.frame[n+1])<<8
+	if fcs16(d.frame[:n]) != fcs {
+		return nil
+	}
+
+	return append([]byte(nil), d.frame[:n]...)
+}
+
+// reset starts looking for a new frame. If inFrame is set, the bits after
+// a flag are collected as the next frame.
+func (d *HDLCDeframer) reset(inFrame bool) {
+	d.inFrame = inFrame
+	d.octet = 0
+	d.nbits = 0
+	d.frame = d.frame[:0]
+}
@@ -1,5 +1,38 @@
 package fap
 
+import (
+	"fmt"
+	"strings"
+)
+
+// EncodeItem creates an APRS item report body.
+// name is 3-9 printable ASCII characters, and may not contain '!' or '_'.
+// Other arguments are as for EncodeObject; items carry no timestamp.
+func EncodeItem(name string, lat, lon float64, speed, course, altitude *float64, symbol string, opts *EncodeObjectOpts) (string, error) {
+	if opts == nil {
+		opts = &EncodeObjectOpts{}
+	}
+
+	if len(name) < 3 || len(name) > 9 {
+		return "", &ParseError{Code: ErrItemEncInvalid.Code, Msg: fmt.Sprintf("item name must be 3-9 characters, got %d", len(name))}
+	}
+	if !isPrintableASCII(name) || strings.ContainsAny(name, "!_") {
+		return "", &ParseError{Code: ErrItemEncInvalid.Code, Msg: fmt.Sprintf("invalid item name: %q", name)}
+	}
+
+	pos, err := encodeObjectPosition(lat, lon, speed, course, altitude, symbol, opts)
+	if err != nil {
+		return "", err
+	}
+
+	alive := "!"
+	if opts.Killed {
+		alive = "_"
+	}
+
+	return ")" + name + alive + pos, nil
+}
+
 // parseItem parses an APRS item packet.
 // Format: )ITEMNAME!LATITUDE/LONGITUDEsymbol...
 func (p *Packet) parseItem(opt *options) error {
@@ -7,7 +40,8 @@ func (p *Packet) parseItem(opt *options) error {
 
 	body := p.Body[1:] // skip ')'
 
-	if len(body) < 18 {
+	// Shortest item: 3-character name, indicator and compressed position
+	if len(body) < 17 {
 		return p.fail(ErrItemShort, "item packet too short")
 	}
 
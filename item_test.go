@@ -214,3 +214,82 @@ func TestItemErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeItem(t *testing.T) {
+	tests := []struct {
+		name     string
+		itemName string
+		lat, lon float64
+		symbol   string
+		opts     *EncodeObjectOpts
+		want     string
+	}{
+		{
+			"uncompressed",
+			"AID #2", 49.05833333333333, -72.02916666666667, "/A", nil,
+			")AID #2!4903.50N/07201.75WA",
+		},
+		{
+			"killed",
+			"AID #2", 49.05833333333333, -72.02916666666667, "/A", &EncodeObjectOpts{Killed: true},
+			")AID #2_4903.50N/07201.75WA",
+		},
+		{
+			"compressed, short name",
+			"X1Y", 60.23049358668088, 24.878968618576835, "//", &EncodeObjectOpts{Compressed: true},
+			")X1Y!/0%E/Th4_/  !",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := EncodeItem(tc.itemName, tc.lat, tc.lon, nil, nil, nil, tc.symbol, tc.opts)
+			if err != nil {
+				t.Fatalf("EncodeItem failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+
+			p, err := Parse("N0CALL-1>APRS:" + got)
+			if err != nil {
+				t.Fatalf("failed to parse encoded item: %v", err)
+			}
+			if p.Type != PacketTypeItem {
+				t.Errorf("type = %q, want %q", p.Type, PacketTypeItem)
+			}
+			if p.ItemName != tc.itemName {
+				t.Errorf("itemname = %q, want %q", p.ItemName, tc.itemName)
+			}
+			killed := tc.opts != nil && tc.opts.Killed
+			if p.Alive == nil || *p.Alive == killed {
+				t.Errorf("alive = %v, want %v", p.Alive, !killed)
+			}
+			if got := fmt.Sprintf("%.4f", *p.Latitude); got != fmt.Sprintf("%.4f", tc.lat) {
+				t.Errorf("latitude = %s, want %.4f", got, tc.lat)
+			}
+		})
+	}
+}
+
+func TestEncodeItemErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		itemName string
+		wantErr  error
+	}{
+		{"name too short", "AB", ErrItemEncInvalid},
+		{"name too long", "ABCDEFGHIJ", ErrItemEncInvalid},
+		{"name with exclamation mark", "AB!C", ErrItemEncInvalid},
+		{"name with underscore", "AB_C", ErrItemEncInvalid},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := EncodeItem(tc.itemName, 0, 0, nil, nil, nil, "/>", nil)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
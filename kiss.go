@@ -0,0 +1,188 @@
+package fap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// KISS special characters.
+const (
+	KISSFEND  = 0xC0 // Frame end
+	KISSFESC  = 0xDB // Frame escape
+	KISSTFEND = 0xDC // Transposed frame end
+	KISSTFESC = 0xDD // Transposed frame escape
+)
+
+// KISS command codes, carried in the low nibble of the type byte.
+const (
+	KISSCmdData        = 0x00 // Data frame
+	KISSCmdTxDelay     = 0x01 // Transmitter keyup delay, in 10 ms units
+	KISSCmdPersistence = 0x02 // Persistence parameter p, (p+1)/256
+	KISSCmdSlotTime    = 0x03 // Slot interval, in 10 ms units
+	KISSCmdTxTail      = 0x04 // Time to hold after transmission, in 10 ms units
+	KISSCmdFullDuplex  = 0x05 // Full duplex mode (0 = half duplex)
+	KISSCmdSetHardware = 0x06 // TNC specific configuration
+	KISSCmdReturn      = 0xFF // Exit KISS mode, sent as a type byte of 0xFF
+)
+
+// kissMaxFrame is the largest KISS frame accepted by KISSReader.
+const kissMaxFrame = 4096
+
+// KISSFrame is a frame exchanged with a KISS TNC.
+type KISSFrame struct {
+	Port    int    // TNC port 0-15
+	Command byte   // Command code, KISSCmdData for AX.25 frames
+	Data    []byte // Frame contents, an AX.25 frame without FCS for data frames
+}
+
+// EncodeKISS wraps a frame in FEND characters, escaping FEND and FESC in
+// the data.
+func EncodeKISS(f *KISSFrame) ([]byte, error) {
+	if f.Port < 0 || f.Port > 15 {
+		return nil, &ParseError{Code: ErrKISSInvalid.Code, Msg: fmt.Sprintf("port out of range: %d", f.Port)}
+	}
+	if f.Command > 0x0F && f.Command != KISSCmdReturn {
+		return nil, &ParseError{Code: ErrKISSInvalid.Code, Msg: fmt.Sprintf("invalid command: 0x%02X", f.Command)}
+	}
+
+	typ := byte(f.Port)<<4 | f.Command
+	if f.Command == KISSCmdReturn {
+		typ = KISSCmdReturn
+	}
+
+	out := make([]byte, 0, len(f.Data)+4)
+	out = append(out, KISSFEND)
+	out = kissEscape(out, []byte{typ})
+	out = kissEscape(out, f.Data)
+	out = append(out, KISSFEND)
+
+	return out, nil
+}
+
+// kissEscape appends data to out, escaping FEND and FESC.
+func kissEscape(out, data []byte) []byte {
+	for _, b := range data {
+		switch b {
+		case KISSFEND:
+			out = append(out, KISSFESC, KISSTFEND)
+		case KISSFESC:
+			out = append(out, KISSFESC, KISSTFESC)
+		default:
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// DecodeKISS decodes a single KISS frame. The surrounding FEND characters
+// are optional.
+func DecodeKISS(frame []byte) (*KISSFrame, error) {
+	for len(frame) > 0 && frame[0] == KISSFEND {
+		frame = frame[1:]
+	}
+	for len(frame) > 0 && frame[len(frame)-1] == KISSFEND {
+		frame = frame[:len(frame)-1]
+	}
+
+	data, err := kissUnescape(frame)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, &ParseError{Code: ErrKISSInvalid.Code, Msg: "empty frame"}
+	}
+
+	f := &KISSFrame{
+		Port:    int(data[0] >> 4),
+		Command: data[0] & 0x0F,
+		Data:    data[1:],
+	}
+	if data[0] == KISSCmdReturn {
+		f.Port = 0
+		f.Command = KISSCmdReturn
+	}
+
+	return f, nil
+}
+
+// kissUnescape removes the escaping of FEND and FESC characters.
+func kissUnescape(frame []byte) ([]byte, error) {
+	data := make([]byte, 0, len(frame))
+	for i := 0; i < len(frame); i++ {
+		b := frame[i]
+		switch b {
+		case KISSFEND:
+			return nil, &ParseError{Code: ErrKISSInvalid.Code, Msg: "FEND inside frame"}
+		case KISSFESC:
+			i++
+			if i >= len(frame) {
+				return nil, &ParseError{Code: ErrKISSInvalid.Code, Msg: "FESC at end of frame"}
+			}
+			switch frame[i] {
+			case KISSTFEND:
+				data = append(data, KISSFEND)
+			case KISSTFESC:
+				data = append(data, KISSFESC)
+			default:
+				return nil, &ParseError{Code: ErrKISSInvalid.Code, Msg: fmt.Sprintf("invalid escape sequence: FESC 0x%02X", frame[i])}
+			}
+		default:
+			data = append(data, b)
+		}
+	}
+	return data, nil
+}
+
+// KISSReader reads KISS frames from a byte stream, such as a serial port
+// or a TCP connection to a software TNC.
+type KISSReader struct {
+	r       *bufio.Reader
+	buf     []byte // contents of the frame being read
+	inFrame bool   // a FEND has been seen
+	tooLong bool   // the frame being read is being discarded
+}
+
+// NewKISSReader creates a KISSReader reading from r.
+func NewKISSReader(r io.Reader) *KISSReader {
+	return &KISSReader{r: bufio.NewReader(r)}
+}
+
+// ReadFrame reads the next frame from the stream. Data before the first
+// FEND and empty frames are skipped. An invalid frame is returned as a
+// *ParseError, after which reading may continue with the next frame.
+// If the underlying reader returns an error, such as a read timeout, a
+// partially read frame is kept and completed by the next call.
+func (kr *KISSReader) ReadFrame() (*KISSFrame, error) {
+	for {
+		b, err := kr.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if b != KISSFEND {
+			if !kr.inFrame {
+				continue
+			}
+			if len(kr.buf) >= kissMaxFrame {
+				kr.tooLong = true
+				continue
+			}
+			kr.buf = append(kr.buf, b)
+			continue
+		}
+
+		// FEND starts or ends a frame, and back-to-back FENDs are
+		// skipped. The closing FEND also opens the next frame.
+		kr.inFrame = true
+		if len(kr.buf) == 0 && !kr.tooLong {
+			continue
+		}
+		frame, tooLong := kr.buf, kr.tooLong
+		kr.buf, kr.tooLong = nil, false
+		if tooLong {
+			return nil, &ParseError{Code: ErrKISSInvalid.Code, Msg: fmt.Sprintf("frame longer than %d bytes", kissMaxFrame)}
+		}
+		return DecodeKISS(frame)
+	}
+}
@@ -0,0 +1,170 @@
+package fap
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestKISSEncode(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame KISSFrame
+		want  []byte
+	}{
+		{
+			"data",
+			KISSFrame{Port: 0, Command: KISSCmdData, Data: []byte("abc")},
+			[]byte{KISSFEND, 0x00, 'a', 'b', 'c', KISSFEND},
+		},
+		{
+			"port and escapes",
+			KISSFrame{Port: 2, Command: KISSCmdData, Data: []byte{KISSFEND, 'x', KISSFESC}},
+			[]byte{KISSFEND, 0x20, KISSFESC, KISSTFEND, 'x', KISSFESC, KISSTFESC, KISSFEND},
+		},
+		{
+			"command",
+			KISSFrame{Port: 1, Command: KISSCmdTxDelay, Data: []byte{50}},
+			[]byte{KISSFEND, 0x11, 50, KISSFEND},
+		},
+		{
+			"escaped type byte",
+			KISSFrame{Port: 12, Command: KISSCmdData, Data: []byte{'x'}},
+			[]byte{KISSFEND, KISSFESC, KISSTFEND, 'x', KISSFEND}, // type byte 0xC0
+		},
+		{
+			"return",
+			KISSFrame{Command: KISSCmdReturn},
+			[]byte{KISSFEND, 0xFF, KISSFEND},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeKISS(&tt.frame)
+			if err != nil {
+				t.Fatalf("EncodeKISS error: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("EncodeKISS = % X, want % X", got, tt.want)
+			}
+
+			f, err := DecodeKISS(got)
+			if err != nil {
+				t.Fatalf("DecodeKISS error: %v", err)
+			}
+			if f.Port != tt.frame.Port || f.Command != tt.frame.Command || !bytes.Equal(f.Data, tt.frame.Data) {
+				t.Errorf("DecodeKISS = %d %02X % X, want %d %02X % X",
+					f.Port, f.Command, f.Data, tt.frame.Port, tt.frame.Command, tt.frame.Data)
+			}
+		})
+	}
+}
+
+func TestKISSEncodeInvalid(t *testing.T) {
+	for _, f := range []KISSFrame{{Port: 16}, {Port: -1}, {Command: 0x10}} {
+		if _, err := EncodeKISS(&f); !errors.Is(err, ErrKISSInvalid) {
+			t.Errorf("EncodeKISS(%+v) error = %v, want %v", f, err, ErrKISSInvalid)
+		}
+	}
+}
+
+func TestKISSDecodeInvalid(t *testing.T) {
+	tests := [][]byte{
+		{},
+		{KISSFEND, KISSFEND},
+		{KISSFEND, 0x00, KISSFESC, KISSFEND},
+		{KISSFEND, 0x00, KISSFESC, 'x', KISSFEND},
+		{0x00, 'a', KISSFEND, 'b'},
+	}
+	for _, in := range tests {
+		if _, err := DecodeKISS(in); !errors.Is(err, ErrKISSInvalid) {
+			t.Errorf("DecodeKISS(% X) error = %v, want %v", in, err, ErrKISSInvalid)
+		}
+	}
+}
+
+func TestKISSReader(t *testing.T) {
+	var stream []byte
+	stream = append(stream, "noise"...)
+	stream = append(stream, KISSFEND, KISSFEND, 0x00, 'a', KISSFEND)       // frame a
+	stream = append(stream, 0x10, 'b', KISSFEND)                           // frame b, sharing the FEND
+	stream = append(stream, KISSFEND, 0x00, KISSFESC, 'x', KISSFEND)       // invalid escape
+	stream = append(stream, KISSFEND, 0x00, KISSFESC, KISSTFEND, KISSFEND) // escaped FEND
+	stream = append(stream, KISSFEND, 0x00, 'c')                           // incomplete
+
+	kr := NewKISSReader(bytes.NewReader(stream))
+
+	want := []struct {
+		port int
+		data string
+		err  bool
+	}{
+		{0, "a", false},
+		{1, "b", false},
+		{0, "", true},
+		{0, "\xC0", false},
+	}
+	for i, w := range want {
+		f, err := kr.ReadFrame()
+		if w.err {
+			if !errors.Is(err, ErrKISSInvalid) {
+				t.Errorf("frame %d: error = %v, want %v", i, err, ErrKISSInvalid)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("frame %d: ReadFrame error: %v", i, err)
+		}
+		if f.Port != w.port || string(f.Data) != w.data {
+			t.Errorf("frame %d: got port %d data %q, want port %d data %q", i, f.Port, f.Data, w.port, w.data)
+		}
+	}
+
+	if _, err := kr.ReadFrame(); err != io.EOF {
+		t.Errorf("ReadFrame at end error = %v, want io.EOF", err)
+	}
+}
+
+// timeoutReader returns an error after each chunk of data, like a
+// connection with a read deadline.
+type timeoutReader struct {
+	chunks [][]byte
+	fail   bool
+}
+
+var errTestTimeout = errors.New("timeout")
+
+func (r *timeoutReader) Read(b []byte) (int, error) {
+	if r.fail {
+		r.fail = false
+		return 0, errTestTimeout
+	}
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	r.fail = true
+	return n, nil
+}
+
+func TestKISSReaderResume(t *testing.T) {
+	r := &timeoutReader{chunks: [][]byte{
+		{KISSFEND, 0x00, 'a', 'b'},
+		{'c', KISSFEND},
+	}}
+	kr := NewKISSReader(r)
+
+	if _, err := kr.ReadFrame(); err != errTestTimeout {
+		t.Fatalf("ReadFrame error = %v, want timeout", err)
+	}
+	f, err := kr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if string(f.Data) != "abc" {
+		t.Errorf("data = %q, want %q", f.Data, "abc")
+	}
+}
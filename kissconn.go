@@ -0,0 +1,217 @@
+package fap
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	kissDialTimeout    = 10 * time.Second // Timeout for connecting to a TNC
+	kissWriteTimeout   = 10 * time.Second // Timeout for sending a frame to a TNC
+	kissReconnectDelay = time.Second      // Minimum interval between connection attempts
+)
+
+// KISSConn is a connection to a KISS TNC over TCP, such as the KISS port
+// of Direwolf or soundmodem. If the connection fails, it is re-established
+// by the next read or write. A KISSConn can be read and written
+// concurrently from different goroutines.
+type KISSConn struct {
+	addr           string
+	opts           []Option // parsing options for ReadPacket
+	reconnectDelay time.Duration
+	writeTimeout   time.Duration
+
+	mu       sync.Mutex
+	conn     net.Conn
+	reader   *KISSReader
+	lastDial time.Time
+	closed   bool
+}
+
+// DialKISS connects to a KISS TNC at addr (host:port). The parsing options
+// are used to decode the packets returned by ReadPacket.
+func DialKISS(addr string, opts ...Option) (*KISSConn, error) {
+	c := &KISSConn{
+		addr:           addr,
+		opts:           opts,
+		reconnectDelay: kissReconnectDelay,
+		writeTimeout:   kissWriteTimeout,
+	}
+
+	if _, _, err := c.connection(time.Now().Add(kissDialTimeout)); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// connection returns the current connection, connecting to the TNC if
+// there is none. Connection attempts are spaced by the reconnect delay,
+// and a wait past the deadline fails with os.ErrDeadlineExceeded.
+func (c *KISSConn) connection(deadline time.Time) (net.Conn, *KISSReader, error) {
+	// The lock is not held while waiting or dialing, so that Close and
+	// the other direction are not blocked.
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return nil, nil, net.ErrClosed
+		}
+		if c.conn != nil {
+			conn, reader := c.conn, c.reader
+			c.mu.Unlock()
+			return conn, reader, nil
+		}
+		wait := time.Until(c.lastDial.Add(c.reconnectDelay))
+		if wait <= 0 {
+			c.lastDial = time.Now()
+			c.mu.Unlock()
+			break
+		}
+		c.mu.Unlock()
+
+		if time.Now().Add(wait).After(deadline) {
+			return nil, nil, os.ErrDeadlineExceeded
+		}
+		time.Sleep(wait)
+	}
+
+	conn, err := net.DialTimeout("tcp", c.addr, min(time.Until(deadline), kissDialTimeout))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		conn.Close()
+		return nil, nil, net.ErrClosed
+	}
+	if c.conn != nil {
+		// Another goroutine connected first
+		conn.Close()
+		return c.conn, c.reader, nil
+	}
+	c.conn = conn
+	c.reader = NewKISSReader(conn)
+
+	return c.conn, c.reader, nil
+}
+
+// drop closes a failed connection, so that the next read or write
+// reconnects.
+func (c *KISSConn) drop(conn net.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == conn {
+		c.conn.Close()
+		c.conn = nil
+		c.reader = nil
+	}
+}
+
+// ReadFrame reads the next KISS frame from the TNC. The provided timeout
+// sets a read deadline, and a timeout is returned as a net.Error with
+// Timeout() true. If the connection fails, it is re-established within
+// the timeout. An invalid frame is returned as a *ParseError.
+func (c *KISSConn) ReadFrame(timeout time.Duration) (*KISSFrame, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, kr, err := c.connection(deadline)
+		if err != nil {
+			return nil, err
+		}
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+
+		f, err := kr.ReadFrame()
+		if err == nil {
+			return f, nil
+		}
+		if _, ok := errors.AsType[*ParseError](err); ok {
+			return nil, err
+		}
+		if ne, ok := errors.AsType[net.Error](err); ok && ne.Timeout() {
+			return nil, err
+		}
+
+		// The connection failed, reconnect
+		c.drop(conn)
+	}
+}
+
+// ReadPacket reads frames until an AX.25 data frame is received, and
+// returns it decoded with DecodeAX25, along with the TNC port it was
+// received on. Other KISS frames are skipped. If the frame cannot be
+// decoded, the packet is returned with the error, like Parse does.
+// The timeout applies to each individual read.
+func (c *KISSConn) ReadPacket(timeout time.Duration) (*Packet, int, error) {
+	for {
+		f, err := c.ReadFrame(timeout)
+		if err != nil {
+			return nil, 0, err
+		}
+		if f.Command != KISSCmdData {
+			continue
+		}
+		p, err := DecodeAX25(f.Data, c.opts...)
+		return p, f.Port, err
+	}
+}
+
+// WriteFrame sends a KISS frame to the TNC, reconnecting first if the
+// connection has failed. A TNC which does not accept the frame within 10
+// seconds fails the write with a net.Error with Timeout() true, and the
+// connection is re-established by the next read or write.
+func (c *KISSConn) WriteFrame(f *KISSFrame) error {
+	data, err := EncodeKISS(f)
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := c.connection(time.Now().Add(kissDialTimeout))
+	if err != nil {
+		return err
+	}
+	if err := conn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+		return err
+	}
+	if _, err := conn.Write(data); err != nil {
+		// A partly written frame would corrupt the next one
+		c.drop(conn)
+		return err
+	}
+	return nil
+}
+
+// SendPacket transmits a packet in TNC2 format (SRC>DST,PATH:body) on a
+// TNC port, encoded with EncodeAX25FromTNC2.
+func (c *KISSConn) SendPacket(port int, packet string) error {
+	frame, err := EncodeAX25FromTNC2(packet, nil)
+	if err != nil {
+		return err
+	}
+	return c.WriteFrame(&KISSFrame{Port: port, Command: KISSCmdData, Data: frame})
+}
+
+// Close closes the connection. Reads and writes after Close fail with
+// net.ErrClosed.
+func (c *KISSConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	c.reader = nil
+	return err
+}
@@ -0,0 +1,236 @@
+package fap
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// kissTestServer accepts connections and runs handle for each of them in
+// turn.
+func kissTestServer(t *testing.T, handlers ...func(conn net.Conn)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for _, handle := range handlers {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			handle(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+// kissTestData returns a KISS-wrapped AX.25 frame of a TNC2 packet.
+func kissTestData(t *testing.T, port int, packet string) []byte {
+	t.Helper()
+	data, err := EncodeAX25FromTNC2(packet, &EncodeAX25Opts{KISS: true, Port: port})
+	if err != nil {
+		t.Fatalf("EncodeAX25FromTNC2 error: %v", err)
+	}
+	return data
+}
+
+func TestKISSConnRead(t *testing.T) {
+	var stream []byte
+	stream = append(stream, kissTestData(t, 0, "N0CALL>APRS,WIDE1-1:>first")...)
+	stream = append(stream, KISSFEND, 0x01, 50, KISSFEND) // TXDELAY, skipped
+	stream = append(stream, kissTestData(t, 2, "N0CALL-9>APDR16:>second")...)
+
+	addr := kissTestServer(t, func(conn net.Conn) {
+		conn.Write(stream)
+		// Keep the connection open until the client closes it
+		conn.Read(make([]byte, 1))
+		conn.Close()
+	})
+
+	c, err := DialKISS(addr, WithDeviceID())
+	if err != nil {
+		t.Fatalf("DialKISS failed: %v", err)
+	}
+	defer c.Close()
+
+	want := []struct {
+		port   int
+		status string
+		device bool // identified with the WithDeviceID option
+	}{
+		{0, "first", false},
+		{2, "second", true},
+	}
+	for _, w := range want {
+		p, port, err := c.ReadPacket(2 * time.Second)
+		if err != nil {
+			t.Fatalf("ReadPacket failed: %v", err)
+		}
+		if port != w.port || p.Status != w.status {
+			t.Errorf("ReadPacket = port %d %q, want port %d %q", port, p.Status, w.port, w.status)
+		}
+		if (p.Device != nil) != w.device {
+			t.Errorf("device = %+v, want identified %v", p.Device, w.device)
+		}
+	}
+
+	// No more data: the read times out
+	_, _, err = c.ReadPacket(50 * time.Millisecond)
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("ReadPacket error = %v, want timeout", err)
+	}
+}
+
+func TestKISSConnReconnect(t *testing.T) {
+	addr := kissTestServer(t,
+		func(conn net.Conn) {
+			conn.Write(kissTestData(t, 0, "N0CALL>APRS:>before"))
+			conn.Close()
+		},
+		func(conn net.Conn) {
+			conn.Write(kissTestData(t, 0, "N0CALL>APRS:>after"))
+			conn.Read(make([]byte, 1))
+			conn.Close()
+		},
+	)
+
+	c, err := DialKISS(addr)
+	if err != nil {
+		t.Fatalf("DialKISS failed: %v", err)
+	}
+	c.reconnectDelay = 10 * time.Millisecond
+	defer c.Close()
+
+	for _, want := range []string{"before", "after"} {
+		p, _, err := c.ReadPacket(2 * time.Second)
+		if err != nil {
+			t.Fatalf("ReadPacket failed: %v", err)
+		}
+		if p.Status != want {
+			t.Errorf("status = %q, want %q", p.Status, want)
+		}
+	}
+}
+
+func TestKISSConnSend(t *testing.T) {
+	received := make(chan []byte, 1)
+	addr := kissTestServer(t, func(conn net.Conn) {
+		defer conn.Close()
+		kr := NewKISSReader(conn)
+		f, err := kr.ReadFrame()
+		if err != nil {
+			received <- nil
+			return
+		}
+		data, _ := EncodeKISS(f)
+		received <- data
+	})
+
+	c, err := DialKISS(addr)
+	if err != nil {
+		t.Fatalf("DialKISS failed: %v", err)
+	}
+	defer c.Close()
+
+	packet := "N0CALL-9>APZ001,WIDE1-1:!6028.51N/02505.68E>Test"
+	if err := c.SendPacket(1, packet); err != nil {
+		t.Fatalf("SendPacket failed: %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if want := kissTestData(t, 1, packet); !bytes.Equal(got, want) {
+			t.Errorf("received % X, want % X", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for frame")
+	}
+
+	if err := c.SendPacket(1, "invalid"); !errors.Is(err, ErrAX25EncInvalid) {
+		t.Errorf("SendPacket error = %v, want %v", err, ErrAX25EncInvalid)
+	}
+}
+
+func TestKISSConnWriteTimeout(t *testing.T) {
+	// The TNC accepts the connection, but never reads from it
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+	addr := kissTestServer(t, func(conn net.Conn) {
+		<-stop
+		conn.Close()
+	})
+
+	c, err := DialKISS(addr)
+	if err != nil {
+		t.Fatalf("DialKISS failed: %v", err)
+	}
+	c.writeTimeout = 100 * time.Millisecond
+	defer c.Close()
+
+	// Fill the socket buffers until a write blocks
+	f := &KISSFrame{Data: make([]byte, 64*1024)}
+	start := time.Now()
+	for time.Since(start) < 5*time.Second {
+		if err = c.WriteFrame(f); err != nil {
+			break
+		}
+	}
+	if ne, ok := errors.AsType[net.Error](err); !ok || !ne.Timeout() {
+		t.Fatalf("WriteFrame error = %v, want timeout", err)
+	}
+}
+
+func TestKISSConnClose(t *testing.T) {
+	addr := kissTestServer(t, func(conn net.Conn) {
+		conn.Read(make([]byte, 1))
+		conn.Close()
+	})
+
+	c, err := DialKISS(addr)
+	if err != nil {
+		t.Fatalf("DialKISS failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.ReadFrame(5 * time.Second)
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	c.Close()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Errorf("ReadFrame error = %v, want %v", err, net.ErrClosed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ReadFrame did not return after Close")
+	}
+
+	if err := c.WriteFrame(&KISSFrame{Data: []byte("x")}); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("WriteFrame error = %v, want %v", err, net.ErrClosed)
+	}
+}
+
+func TestDialKISSFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	if _, err := DialKISS(addr); err == nil {
+		t.Error("DialKISS succeeded, want error")
+	}
+}
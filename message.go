@@ -132,6 +132,11 @@ func (p *Packet) parseMessage(opt *options) error {
 	// Catch telemetry parameter messages (PARM, UNIT, EQNS, BITS)
 	if isTelemetryMessage(msg.Text) {
 		p.Type = PacketTypeTelemetryMessage
+		p.parseTelemetryDefinition(msg.Text)
+	} else if qt, ok := directedQueries[strings.TrimSpace(msg.Text)]; ok {
+		// Catch directed queries (?APRSP etc)
+		p.Type = PacketTypeQuery
+		p.Query = &Query{Type: qt, Directed: true}
 	}
 
 	return nil
@@ -0,0 +1,173 @@
+package fap
+
+import (
+	"fmt"
+	"math"
+)
+
+// EncodeMicEOpts contains optional parameters for EncodeMicE.
+type EncodeMicEOpts struct {
+	Message      string     // message type as returned by MicEMBitsToMessage, default "Off Duty"
+	OldGPS       bool       // position is not current, use ' instead of ` as the data type identifier
+	Altitude     *float64   // altitude in meters
+	Comment      string     // comment to append
+	Telemetry    *Telemetry // base-91 telemetry to append to the comment
+	DeviceSuffix string     // 2-character device identification code appended at the end, e.g. "_\""
+}
+
+// micEMessageBits maps the standard Mic-E message types to message bits.
+var micEMessageBits = map[string]string{
+	"Off Duty":   "111",
+	"En Route":   "110",
+	"In Service": "101",
+	"Returning":  "100",
+	"Committed":  "011",
+	"Special":    "010",
+	"Priority":   "001",
+	"Emergency":  "000",
+}
+
+// EncodeMicE creates a Mic-E encoded position. Mic-E carries the latitude
+// and message type in the destination callsign, so both the destination
+// callsign and the information field body are returned.
+// lat/lon are in decimal degrees, speed in km/h and course in degrees.
+// symbol is a 2-character string (table + code).
+func EncodeMicE(lat, lon float64, speed, course *float64, symbol string, opts *EncodeMicEOpts) (string, string, error) {
+	if opts == nil {
+		opts = &EncodeMicEOpts{}
+	}
+
+	// Validate coordinates
+	if lat < -89.99999 || lat > 89.99999 || lon < -179.99999 || lon > 179.99999 {
+		return "", "", &ParseError{Code: ErrMiceEncInvalid.Code, Msg: fmt.Sprintf("invalid coordinates: lat=%f lon=%f", lat, lon)}
+	}
+
+	symbolTable, symbolCode, err := parseEncodeSymbol(symbol)
+	if err != nil {
+		return "", "", err
+	}
+
+	message := opts.Message
+	if message == "" {
+		message = "Off Duty"
+	}
+	mbits, ok := micEMessageBits[message]
+	if !ok {
+		return "", "", &ParseError{Code: ErrMiceEncInvalid.Code, Msg: fmt.Sprintf("unknown Mic-E message type: %q", message)}
+	}
+
+	// Latitude and longitude in hundredths of minutes
+	latDeg, latHMin := micEDegreesMinutes(math.Abs(lat))
+	lonDeg, lonHMin := micEDegreesMinutes(math.Abs(lon))
+	if lonDeg > 179 {
+		lonDeg, lonHMin = 179, 5999
+	}
+
+	// Destination callsign: 6 latitude digits, with message bits in the
+	// first three, N/S in the fourth, longitude offset in the fifth and
+	// E/W in the sixth. Digits in the P-Y range signal a 1 bit.
+	digits := fmt.Sprintf("%02d%04d", latDeg, latHMin)
+	flags := [6]bool{
+		mbits[0] == '1',
+		mbits[1] == '1',
+		mbits[2] == '1',
+		lat >= 0,
+		lonDeg < 10 || lonDeg >= 100,
+		lon < 0,
+	}
+	dst := make([]byte, 6)
+	for i := range 6 {
+		dst[i] = digits[i]
+		if flags[i] {
+			dst[i] = digits[i] - '0' + 'P'
+		}
+	}
+
+	// Longitude degrees
+	var d int
+	switch {
+	case lonDeg < 10:
+		d = lonDeg + 118
+	case lonDeg < 100:
+		d = lonDeg + 28
+	case lonDeg < 110:
+		d = lonDeg + 8
+	default:
+		d = lonDeg - 72
+	}
+
+	// Longitude minutes, 0-9 are encoded as 60-69
+	lonMin := lonHMin / 100
+	m := lonMin + 28
+	if lonMin < 10 {
+		m = lonMin + 88
+	}
+	h := lonHMin%100 + 28
+
+	// Speed in knots and course in degrees
+	knots := 0
+	if speed != nil && *speed > 0 {
+		knots = min(int(math.Round(*speed/1.852)), 799)
+	}
+	crs := 0
+	if course != nil && *course > 0 {
+		crs = int(math.Round(*course)) % 361
+	}
+	sp := knots/10 + 28
+	if sp < 0x20 {
+		// Avoid control characters, speeds of 800 and over wrap around
+		sp += 80
+	}
+	dc := (knots%10)*10 + crs/100 + 28
+	if dc < 0x20 {
+		// Avoid control characters, courses of 400 and over wrap around
+		dc += 4
+	}
+	se := crs%100 + 28
+
+	dti := byte('`')
+	if opts.OldGPS {
+		dti = '\''
+	}
+
+	body := string([]byte{dti, byte(d), byte(m), byte(h), byte(sp), byte(dc), byte(se), symbolCode, symbolTable})
+
+	// A device identification code is marked by a '`' at the start of
+	// the comment, before the altitude
+	if opts.DeviceSuffix != "" {
+		if len(opts.DeviceSuffix) != 2 {
+			return "", "", &ParseError{Code: ErrMiceEncInvalid.Code, Msg: fmt.Sprintf("device suffix is not 2 characters: %q", opts.DeviceSuffix)}
+		}
+		body += "`"
+	}
+
+	// Altitude: 3 base-91 digits, meters above -10000 m, terminated by '}'
+	if opts.Altitude != nil {
+		alt := int(math.Round(*opts.Altitude)) + 10000
+		if alt < 0 || alt > 91*91*91-1 {
+			return "", "", &ParseError{Code: ErrMiceEncInvalid.Code, Msg: fmt.Sprintf("altitude out of range: %f", *opts.Altitude)}
+		}
+		body += encodeBase91(alt, 3) + "}"
+	}
+
+	body += opts.Comment
+
+	if opts.Telemetry != nil {
+		tlm, err := EncodeBase91Telemetry(opts.Telemetry)
+		if err != nil {
+			return "", "", err
+		}
+		body += tlm
+	}
+
+	body += opts.DeviceSuffix
+
+	return string(dst), body, nil
+}
+
+// micEDegreesMinutes splits a non-negative coordinate into whole degrees
+// and hundredths of minutes, rounding to the nearest hundredth.
+func micEDegreesMinutes(v float64) (int, int) {
+	total := int(math.Round(v * 6000))
+	return total / 6000, total % 6000
+}
@@ -0,0 +1,164 @@
+package fap
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEncodeMicE(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lon float64
+		speed    *float64
+		course   *float64
+		symbol   string
+		opts     *EncodeMicEOpts
+		wantDst  string
+		wantBody string
+	}{
+		{
+			// Same position and symbol as TestMicENonMoving
+			"non-moving, south east",
+			-38.25600, 145.18600, nil, nil, "/>", &EncodeMicEOpts{OldGPS: true, Message: "En Route"},
+			"SX15S6", "'I',l \x1c>/",
+		},
+		{
+			"north west, off duty",
+			33.42733333333333, -112.129, nil, nil, "/>", nil,
+			"SSRUVT", "`(_fl \x1c>/",
+		},
+		{
+			"moving with altitude and comment",
+			60.0, 24.0, new(20 * 1.852), new(251.0), "/>",
+			&EncodeMicEOpts{Message: "In Service", Altitude: new(61.0), Comment: "Hello"},
+			"V0PP00", "`4X\x1cn\"O>/\"4T}Hello",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dst, body, err := EncodeMicE(tc.lat, tc.lon, tc.speed, tc.course, tc.symbol, tc.opts)
+			if err != nil {
+				t.Fatalf("EncodeMicE failed: %v", err)
+			}
+			if dst != tc.wantDst {
+				t.Errorf("dst = %q, want %q", dst, tc.wantDst)
+			}
+			if body != tc.wantBody {
+				t.Errorf("body = %q, want %q", body, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestEncodeMicERoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lon float64
+		speed    float64
+		course   float64
+	}{
+		{"north east", 60.1234, 24.5678, 0, 0},
+		{"south west", -33.8688, -151.2093, 50, 90},
+		{"lon below 10", 51.4779, -0.0015, 120, 360},
+		{"lon 100-109", 13.7563, 100.5018, 15, 3},
+		{"lon 110-179", -41.2865, 174.7762, 1480, 359},
+		{"lon 10-99", 40.4168, -3.7038, 9.26, 180},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for mbits, message := range map[string]string{"111": "Off Duty", "010": "Special", "000": "Emergency"} {
+				tlm := &Telemetry{Seq: 123, Vals: []*float64{new(1.0), new(8280.0), new(0.0), new(42.0), new(7.0)}, Bits: "10110001"}
+				dst, body, err := EncodeMicE(tc.lat, tc.lon, &tc.speed, &tc.course, "\\k", &EncodeMicEOpts{
+					Message:      message,
+					Altitude:     new(-20.0),
+					Comment:      "comment text",
+					Telemetry:    tlm,
+					DeviceSuffix: "_\"",
+				})
+				if err != nil {
+					t.Fatalf("EncodeMicE failed: %v", err)
+				}
+				// The device code is marked with '`' after the fixed fields
+				if body[9] != '`' || !strings.HasSuffix(body, "|_\"") {
+					t.Errorf("body = %q, want '`' at offset 9 and device code at the end", body)
+				}
+				p, err := Parse("N0CALL>" + dst + ":" + body)
+				if err != nil {
+					t.Fatalf("failed to parse %q: %v", body, err)
+				}
+				if p.Format != FormatMicE {
+					t.Errorf("format = %q, want %q", p.Format, FormatMicE)
+				}
+				if p.MBits != mbits || MicEMBitsToMessage(p.MBits) != message {
+					t.Errorf("mbits = %q, want %q", p.MBits, mbits)
+				}
+				if math.Abs(*p.Latitude-tc.lat) > 0.0001 {
+					t.Errorf("latitude = %f, want %f", *p.Latitude, tc.lat)
+				}
+				if math.Abs(*p.Longitude-tc.lon) > 0.0001 {
+					t.Errorf("longitude = %f, want %f", *p.Longitude, tc.lon)
+				}
+				if math.Abs(*p.Speed-tc.speed) > 1.852/2 {
+					t.Errorf("speed = %f, want %f", *p.Speed, tc.speed)
+				}
+				if *p.Course != int(tc.course) {
+					t.Errorf("course = %d, want %d", *p.Course, int(tc.course))
+				}
+				if p.SymbolTable != '\\' || p.SymbolCode != 'k' {
+					t.Errorf("symbol = %c%c, want \\k", p.SymbolTable, p.SymbolCode)
+				}
+				if p.Altitude == nil || *p.Altitude != -20 {
+					t.Errorf("altitude = %v, want -20", p.Altitude)
+				}
+				if p.Comment != "`comment text_\"" {
+					t.Errorf("comment = %q, want %q", p.Comment, "`comment text_\"")
+				}
+				if p.TelemetryData == nil {
+					t.Fatal("telemetry is nil")
+				}
+				if p.TelemetryData.Seq != 123 || p.TelemetryData.Bits != "10110001" {
+					t.Errorf("telemetry seq/bits = %d/%q, want 123/%q", p.TelemetryData.Seq, p.TelemetryData.Bits, "10110001")
+				}
+				for i, v := range tlm.Vals {
+					if p.TelemetryData.Vals[i] == nil || *p.TelemetryData.Vals[i] != *v {
+						t.Errorf("telemetry value %d = %v, want %f", i+1, p.TelemetryData.Vals[i], *v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeMicEErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		lat     float64
+		lon     float64
+		symbol  string
+		opts    *EncodeMicEOpts
+		wantErr error
+	}{
+		{"lat too high", 91.0, 0.0, "/>", nil, ErrMiceEncInvalid},
+		{"lon too low", 0.0, -181.0, "/>", nil, ErrMiceEncInvalid},
+		{"invalid symbol table", 0.0, 0.0, "a>", nil, ErrPosEncInvalid},
+		{"unknown message", 0.0, 0.0, "/>", &EncodeMicEOpts{Message: "Unknown"}, ErrMiceEncInvalid},
+		{"altitude too low", 0.0, 0.0, "/>", &EncodeMicEOpts{Altitude: new(-10001.0)}, ErrMiceEncInvalid},
+		{"device suffix too long", 0.0, 0.0, "/>", &EncodeMicEOpts{DeviceSuffix: "`_\""}, ErrMiceEncInvalid},
+		{"telemetry value too large", 0.0, 0.0, "/>", &EncodeMicEOpts{Telemetry: &Telemetry{Vals: []*float64{new(8281.0)}}}, ErrTlmEncInvalid},
+		{"telemetry with no values", 0.0, 0.0, "/>", &EncodeMicEOpts{Telemetry: &Telemetry{}}, ErrTlmEncInvalid},
+		{"telemetry bits without values", 0.0, 0.0, "/>", &EncodeMicEOpts{Telemetry: &Telemetry{Bits: "00000000"}}, ErrTlmEncInvalid},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := EncodeMicE(tc.lat, tc.lon, nil, nil, tc.symbol, tc.opts)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
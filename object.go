@@ -2,8 +2,73 @@ package fap
 
 import (
 	"fmt"
+	"strings"
+	"time"
 )
 
+// EncodeObjectOpts contains optional parameters for EncodeObject and EncodeItem.
+// The embedded position options apply to the position part of the report;
+// MessagingCapable is not used. Objects are timestamped with the DDHHMMz
+// form of Timestamp, or the current time if it is zero. Items have no
+// timestamp.
+type EncodeObjectOpts struct {
+	EncodePositionOpts
+	Killed     bool // report the object or item as killed
+	Compressed bool // use compressed position format
+}
+
+// EncodeObject creates an APRS object report body.
+// name is 1-9 printable ASCII characters, padded with spaces to 9 characters.
+// Other arguments are as for EncodePosition.
+func EncodeObject(name string, lat, lon float64, speed, course, altitude *float64, symbol string, opts *EncodeObjectOpts) (string, error) {
+	if opts == nil {
+		opts = &EncodeObjectOpts{}
+	}
+
+	if len(name) < 1 || len(name) > 9 {
+		return "", &ParseError{Code: ErrObjEncInvalid.Code, Msg: fmt.Sprintf("object name must be 1-9 characters, got %d", len(name))}
+	}
+	if !isPrintableASCII(name) || strings.TrimSpace(name) == "" {
+		return "", &ParseError{Code: ErrObjEncInvalid.Code, Msg: fmt.Sprintf("invalid object name: %q", name)}
+	}
+
+	pos, err := encodeObjectPosition(lat, lon, speed, course, altitude, symbol, opts)
+	if err != nil {
+		return "", err
+	}
+
+	alive := "*"
+	if opts.Killed {
+		alive = "_"
+	}
+
+	ts := opts.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	ts = ts.UTC()
+
+	return fmt.Sprintf(";%-9s%s%02d%02d%02dz%s", name, alive, ts.Day(), ts.Hour(), ts.Minute(), pos), nil
+}
+
+// encodeObjectPosition creates the position part of an object or item report.
+func encodeObjectPosition(lat, lon float64, speed, course, altitude *float64, symbol string, opts *EncodeObjectOpts) (string, error) {
+	if opts.Compressed {
+		return encodeCompressedCoords(lat, lon, speed, course, altitude, symbol, &opts.EncodePositionOpts)
+	}
+	return encodeUncompressedCoords(lat, lon, speed, course, altitude, symbol, &opts.EncodePositionOpts)
+}
+
+// isPrintableASCII checks that s consists of printable ASCII characters (0x20-0x7e).
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
 // parseObject parses an APRS object packet.
 // Format: ;OBJNAME  *DDMMSS/LATITUDE/LONGITUDEsymbol...
 func (p *Packet) parseObject(opt *options) error {
@@ -11,7 +76,9 @@ func (p *Packet) parseObject(opt *options) error {
 
 	body := p.Body[1:] // skip ';'
 
-	if len(body) < 31 {
+	// Name, indicator and timestamp take 17 characters, and the shortest
+	// (compressed) position 13 more.
+	if len(body) < 30 {
 		return p.fail(ErrObjShort, "object packet too short")
 	}
 
@@ -3,7 +3,10 @@ package fap
 import (
 	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Tests ported from perl-aprs-fap/t/41decode-object.t
@@ -160,3 +163,104 @@ func TestObjectErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeObject(t *testing.T) {
+	ts := time.Date(2024, 3, 9, 23, 45, 10, 0, time.UTC)
+	tests := []struct {
+		name     string
+		objName  string
+		lat, lon float64
+		speed    *float64
+		course   *float64
+		symbol   string
+		opts     *EncodeObjectOpts
+		want     string
+	}{
+		{
+			"uncompressed with course/speed",
+			"LEADER", 49.05833333333333, -72.02916666666667, new(36 * 1.852), new(88.0), "/>",
+			&EncodeObjectOpts{EncodePositionOpts: EncodePositionOpts{Timestamp: ts}},
+			";LEADER   *092345z4903.50N/07201.75W>088/036",
+		},
+		{
+			"killed",
+			"LEADER", 49.05833333333333, -72.02916666666667, nil, nil, "/>",
+			&EncodeObjectOpts{Killed: true, EncodePositionOpts: EncodePositionOpts{Timestamp: ts}},
+			";LEADER   _092345z4903.50N/07201.75W>",
+		},
+		{
+			"compressed with comment",
+			"SRAL HQ", 60.23049358668088, 24.878968618576835, nil, nil, "Sa",
+			&EncodeObjectOpts{Compressed: true, EncodePositionOpts: EncodePositionOpts{Timestamp: ts, Comment: "open M-Th"}},
+			";SRAL HQ  *092345zS0%E/Th4_a  !open M-Th",
+		},
+		{
+			"compressed, no comment",
+			"X", 60.23049358668088, 24.878968618576835, nil, nil, "//",
+			&EncodeObjectOpts{Compressed: true, EncodePositionOpts: EncodePositionOpts{Timestamp: ts}},
+			";X        *092345z/0%E/Th4_/  !",
+		},
+		{
+			"full length name",
+			"ABCDEFGHI", 49.05833333333333, -72.02916666666667, nil, nil, "/>",
+			&EncodeObjectOpts{EncodePositionOpts: EncodePositionOpts{Timestamp: ts}},
+			";ABCDEFGHI*092345z4903.50N/07201.75W>",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := EncodeObject(tc.objName, tc.lat, tc.lon, tc.speed, tc.course, nil, tc.symbol, tc.opts)
+			if err != nil {
+				t.Fatalf("EncodeObject failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+
+			p, err := Parse("N0CALL-1>APRS:" + got)
+			if err != nil {
+				t.Fatalf("failed to parse encoded object: %v", err)
+			}
+			if p.Type != PacketTypeObject {
+				t.Errorf("type = %q, want %q", p.Type, PacketTypeObject)
+			}
+			if strings.TrimRight(p.ObjectName, " ") != tc.objName {
+				t.Errorf("objectname = %q, want %q", p.ObjectName, tc.objName)
+			}
+			if p.Alive == nil || *p.Alive == tc.opts.Killed {
+				t.Errorf("alive = %v, want %v", p.Alive, !tc.opts.Killed)
+			}
+			if p.Timestamp == nil || p.Timestamp.Day() != 9 || p.Timestamp.Hour() != 23 || p.Timestamp.Minute() != 45 {
+				t.Errorf("timestamp = %v, want day 9 23:45", p.Timestamp)
+			}
+			if math.Abs(*p.Latitude-tc.lat) > 0.0001 || math.Abs(*p.Longitude-tc.lon) > 0.0001 {
+				t.Errorf("position = %f %f, want %f %f", *p.Latitude, *p.Longitude, tc.lat, tc.lon)
+			}
+		})
+	}
+}
+
+func TestEncodeObjectErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		objName string
+		lat     float64
+		wantErr error
+	}{
+		{"empty name", "", 0, ErrObjEncInvalid},
+		{"name too long", "ABCDEFGHIJ", 0, ErrObjEncInvalid},
+		{"blank name", "   ", 0, ErrObjEncInvalid},
+		{"control character in name", "A\x01B", 0, ErrObjEncInvalid},
+		{"invalid position", "TEST", 91, ErrPosEncInvalid},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := EncodeObject(tc.objName, tc.lat, 0, nil, nil, nil, "/>", nil)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -160,7 +160,11 @@ func (p *Packet) parseCompressedPosition(body string, opt *options) error {
 		return p.fail(ErrCompShort, "compressed position too short")
 	}
 
+	// Overlay digits 0-9 are sent as a-j in compressed positions
 	p.SymbolTable = body[0]
+	if p.SymbolTable >= 'a' && p.SymbolTable <= 'j' {
+		p.SymbolTable = p.SymbolTable - 'a' + '0'
+	}
 
 	// Decode latitude (4 bytes, base-91)
 	lat := 90.0 - float64(
@@ -245,9 +249,27 @@ func (p *Packet) parseCompressedPosition(body string, opt *options) error {
 			return nil
 		}
 
+		// Area objects and signposts carry their own data extensions
+		if p.Type == PacketTypeObject || p.Type == PacketTypeItem {
+			comment = p.parseSpecialObject(comment)
+		}
+
+		// DF report bearing and NRQ follow the compressed position
+		if p.SymbolCode == '\\' {
+			comment = p.parseDFBearing(comment)
+		}
+
+		// DFS omni-DF signal strength
+		comment = p.parseDFS(comment)
+
 		// Strip inline telemetry |...|
 		comment = stripInlineTelemetry(comment)
 
+		// Altitude in the comment, if the cs bytes did not carry one
+		if p.Altitude == nil {
+			comment = p.parseAltitude(comment)
+		}
+
 		// Check for DAO extension
 		comment = p.parseDAO(comment)
 
@@ -287,6 +309,11 @@ func (p *Packet) parsePositionComment(comment string) {
 		return
 	}
 
+	// Area objects and signposts carry their own data extensions
+	if p.Type == PacketTypeObject || p.Type == PacketTypeItem {
+		comment = p.parseSpecialObject(comment)
+	}
+
 	// Check for PHG data (PHGRA 5-char variant, or standard 4-char PHG)
 	if strings.HasPrefix(comment, "PHG") && len(comment) >= 7 && isPhgBase(comment[3:7]) {
 		if len(comment) >= 9 && isUpperAlphaNum(comment[7]) && comment[8] == '/' {
@@ -309,6 +336,9 @@ func (p *Packet) parsePositionComment(comment string) {
 		}
 	}
 
+	// Check for DFS (omni-DF signal strength)
+	comment = p.parseDFS(comment)
+
 	// Check for course/speed: CCC/SSS
 	if len(comment) >= 7 && comment[3] == '/' {
 		courseStr := comment[0:3]
@@ -320,18 +350,16 @@ func (p *Packet) parsePositionComment(comment string) {
 			speedKmh := float64(speed) * 1.852 // knots to km/h
 			p.Speed = &speedKmh
 			comment = comment[7:]
+
+			// DF report bearing and NRQ follow the course and speed
+			if p.SymbolCode == '\\' {
+				comment = p.parseDFBearing(comment)
+			}
 		}
 	}
 
 	// Check for altitude: /A=NNNNNN
-	if idx := strings.Index(comment, "/A="); idx >= 0 && len(comment) >= idx+9 {
-		altStr := comment[idx+3 : idx+9]
-		if alt, err := strconv.Atoi(altStr); err == nil {
-			altM := float64(alt) * 0.3048 // feet to meters
-			p.Altitude = &altM
-			comment = comment[:idx] + comment[idx+9:]
-		}
-	}
+	comment = p.parseAltitude(comment)
 
 	// Check for DAO extension: !Wxx! or similar
 	comment = p.parseDAO(comment)
@@ -344,6 +372,20 @@ func (p *Packet) parsePositionComment(comment string) {
 	p.Comment = strings.TrimSpace(comment)
 }
 
+// parseAltitude parses a /A=NNNNNN altitude (in feet) from a comment.
+// Returns the comment with the altitude removed.
+func (p *Packet) parseAltitude(comment string) string {
+	if idx := strings.Index(comment, "/A="); idx >= 0 && len(comment) >= idx+9 {
+		altStr := comment[idx+3 : idx+9]
+		if alt, err := strconv.Atoi(altStr); err == nil {
+			altM := float64(alt) * 0.3048 // feet to meters
+			p.Altitude = &altM
+			comment = comment[:idx] + comment[idx+9:]
+		}
+	}
+	return comment
+}
+
 // parseDAO parses DAO extensions from comments.
 // Returns the comment with the DAO extension removed.
 func (p *Packet) parseDAO(comment string) string {
@@ -2,19 +2,53 @@ package fap
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"time"
 )
 
-// EncodePositionOpts contains optional parameters for EncodePosition.
+// EncodePositionOpts contains optional parameters for EncodePosition and
+// EncodeCompressedPosition.
 type EncodePositionOpts struct {
-	Ambiguity        int       // 0-4
+	Ambiguity        int       // 0-4 (uncompressed only)
 	Timestamp        time.Time // if non-zero, include HHMMSSh UTC timestamp
 	MessagingCapable bool      // report that the station can receive text messages
-	DAO              bool      // enable !DAO! extension for extra precision
+	DAO              bool      // enable !DAO! extension for extra precision (uncompressed only)
 	Comment          string    // comment to append
+
+	// Compressed format only
+	RadioRange *float64          // radio range in km, sent when course/speed and altitude are absent
+	GPSFix     bool              // compression type: GPS fix is current
+	NMEASource CompressionSource // compression type: NMEA source of the position
+	Origin     CompressionOrigin // compression type: origin of the compressed position
 }
 
+// CompressionSource is the NMEA source field (bits 3-4) of the compression
+// type byte in a compressed position report.
+type CompressionSource int
+
+const (
+	CompressionSourceOther CompressionSource = 0 // other / unknown
+	CompressionSourceGLL   CompressionSource = 1 // GLL sentence
+	CompressionSourceGGA   CompressionSource = 2 // GGA sentence (cs bytes carry altitude)
+	CompressionSourceRMC   CompressionSource = 3 // RMC sentence
+)
+
+// CompressionOrigin is the origin field (bits 0-2) of the compression type
+// byte in a compressed position report.
+type CompressionOrigin int
+
+const (
+	CompressionOriginCompressed   CompressionOrigin = 0 // compressed
+	CompressionOriginTNCBText     CompressionOrigin = 1 // TNC BText
+	CompressionOriginSoftware     CompressionOrigin = 2 // software (DOS, Mac, Win, etc.)
+	CompressionOriginTBD          CompressionOrigin = 3 // reserved
+	CompressionOriginKPC3         CompressionOrigin = 4 // KPC3
+	CompressionOriginPico         CompressionOrigin = 5 // Pico
+	CompressionOriginOtherTracker CompressionOrigin = 6 // other tracker
+	CompressionOriginDigipeater   CompressionOrigin = 7 // digipeater conversion
+)
+
 // formatMinutes converts fractional minutes to a string for APRS position encoding.
 // With dao=true, returns 4-digit minutes string and 2-digit DAO extension.
 // With dao=false, returns 4-digit minutes string and empty DAO.
@@ -43,29 +77,29 @@ func EncodePosition(lat, lon float64, speed, course, altitude *float64, symbol s
 		opts = &EncodePositionOpts{}
 	}
 
-	// If ambiguity is set, DAO is not applicable
-	if opts.Ambiguity > 0 {
-		opts.DAO = false
+	body, err := encodeUncompressedCoords(lat, lon, speed, course, altitude, symbol, opts)
+	if err != nil {
+		return "", err
 	}
 
+	return positionPrefix(opts) + body, nil
+}
+
+// encodeUncompressedCoords creates the uncompressed position data, without
+// the data type identifier, followed by the extensions and comment.
+func encodeUncompressedCoords(lat, lon float64, speed, course, altitude *float64, symbol string, opts *EncodePositionOpts) (string, error) {
+	// If ambiguity is set, DAO is not applicable
+	dao := opts.DAO && opts.Ambiguity <= 0
+
 	// Validate coordinates
 	if lat < -89.99999 || lat > 89.99999 || lon < -179.99999 || lon > 179.99999 {
 		return "", &ParseError{Code: ErrPosEncInvalid.Code, Msg: fmt.Sprintf("invalid coordinates: lat=%f lon=%f", lat, lon)}
 	}
 
 	// Parse symbol
-	var symbolTable, symbolCode byte
-	if len(symbol) == 2 {
-		symbolTable = symbol[0]
-		symbolCode = symbol[1]
-		if !isValidSymbolTable(symbolTable) {
-			return "", &ParseError{Code: ErrPosEncInvalid.Code, Msg: fmt.Sprintf("invalid symbol table: %c", symbolTable)}
-		}
-		if symbolCode < 0x21 || symbolCode > 0x7b && symbolCode != 0x7d {
-			return "", &ParseError{Code: ErrPosEncInvalid.Code, Msg: fmt.Sprintf("invalid symbol code: %c", symbolCode)}
-		}
-	} else {
-		return "", &ParseError{Code: ErrPosEncInvalid.Code, Msg: fmt.Sprintf("invalid symbol length: %d", len(symbol))}
+	symbolTable, symbolCode, err := parseEncodeSymbol(symbol)
+	if err != nil {
+		return "", err
 	}
 
 	// Convert latitude to degrees and minutes
@@ -77,7 +111,7 @@ func EncodePosition(lat, lon float64, speed, course, altitude *float64, symbol s
 	latDeg := int(lat)
 	latMin := (lat - float64(latDeg)) * 60
 
-	latMinS, latMinDAO := formatMinutes(latMin, opts.DAO)
+	latMinS, latMinDAO := formatMinutes(latMin, dao)
 
 	latString := fmt.Sprintf("%02d%s.%s", latDeg, latMinS[0:2], latMinS[2:4])
 
@@ -113,7 +147,7 @@ func EncodePosition(lat, lon float64, speed, course, altitude *float64, symbol s
 	lonDeg := int(lon)
 	lonMin := (lon - float64(lonDeg)) * 60
 
-	lonMinS, lonMinDAO := formatMinutes(lonMin, opts.DAO)
+	lonMinS, lonMinDAO := formatMinutes(lonMin, dao)
 
 	lonString := fmt.Sprintf("%03d%s.%s", lonDeg, lonMinS[0:2], lonMinS[2:4])
 
@@ -140,27 +174,7 @@ func EncodePosition(lat, lon float64, speed, course, altitude *float64, symbol s
 		lonString += "W"
 	}
 
-	// Build result - data type identifier depends on timestamp and messaging capability:
-	//   ! = no timestamp, no messaging
-	//   = = no timestamp, messaging capable
-	//   / = timestamp, no messaging
-	//   @ = timestamp, messaging capable
-	var result string
-	if !opts.Timestamp.IsZero() {
-		utc := opts.Timestamp.UTC()
-		dtid := byte('/')
-		if opts.MessagingCapable {
-			dtid = '@'
-		}
-		result = fmt.Sprintf("%c%02d%02d%02dh", dtid, utc.Hour(), utc.Minute(), utc.Second())
-	} else {
-		if opts.MessagingCapable {
-			result = "="
-		} else {
-			result = "!"
-		}
-	}
-	result += latString + string(symbolTable) + lonString + string(symbolCode)
+	result := latString + string(symbolTable) + lonString + string(symbolCode)
 
 	// Add course/speed if both provided
 	if speed != nil && course != nil && *speed >= 0 && *course >= 0 {
@@ -177,12 +191,7 @@ func EncodePosition(lat, lon float64, speed, course, altitude *float64, symbol s
 
 	// Add altitude if provided
 	if altitude != nil {
-		altFeet := *altitude / 0.3048
-		if altFeet >= 0 {
-			result += fmt.Sprintf("/A=%06.0f", altFeet)
-		} else {
-			result += fmt.Sprintf("/A=-%05.0f", -altFeet)
-		}
+		result += encodeAltitudeComment(*altitude)
 	}
 
 	// Add comment
@@ -191,7 +200,7 @@ func EncodePosition(lat, lon float64, speed, course, altitude *float64, symbol s
 	}
 
 	// Add DAO extension
-	if opts.DAO && latMinDAO != "" && lonMinDAO != "" {
+	if dao && latMinDAO != "" && lonMinDAO != "" {
 		latDAO, _ := strconv.Atoi(latMinDAO)
 		lonDAO, _ := strconv.Atoi(lonMinDAO)
 		latChar := byte(int(float64(latDAO)/1.1+0.5) + 33)
@@ -201,3 +210,167 @@ func EncodePosition(lat, lon float64, speed, course, altitude *float64, symbol s
 
 	return result, nil
 }
+
+// parseEncodeSymbol validates a 2-character symbol string (table + code)
+// and returns its table and code bytes.
+func parseEncodeSymbol(symbol string) (byte, byte, error) {
+	if len(symbol) != 2 {
+		return 0, 0, &ParseError{Code: ErrPosEncInvalid.Code, Msg: fmt.Sprintf("invalid symbol length: %d", len(symbol))}
+	}
+	symbolTable := symbol[0]
+	symbolCode := symbol[1]
+	if !isValidSymbolTable(symbolTable) {
+		return 0, 0, &ParseError{Code: ErrPosEncInvalid.Code, Msg: fmt.Sprintf("invalid symbol table: %c", symbolTable)}
+	}
+	if symbolCode < 0x21 || symbolCode > 0x7b && symbolCode != 0x7d {
+		return 0, 0, &ParseError{Code: ErrPosEncInvalid.Code, Msg: fmt.Sprintf("invalid symbol code: %c", symbolCode)}
+	}
+	return symbolTable, symbolCode, nil
+}
+
+// positionPrefix returns the data type identifier and optional timestamp
+// for a position report. The identifier depends on timestamp and messaging
+// capability:
+//
+//	! = no timestamp, no messaging
+//	= = no timestamp, messaging capable
+//	/ = timestamp, no messaging
+//	@ = timestamp, messaging capable
+func positionPrefix(opts *EncodePositionOpts) string {
+	if !opts.Timestamp.IsZero() {
+		utc := opts.Timestamp.UTC()
+		dtid := byte('/')
+		if opts.MessagingCapable {
+			dtid = '@'
+		}
+		return fmt.Sprintf("%c%02d%02d%02dh", dtid, utc.Hour(), utc.Minute(), utc.Second())
+	}
+	if opts.MessagingCapable {
+		return "="
+	}
+	return "!"
+}
+
+// compressedTableChar maps a symbol table or overlay character to the
+// character used in compressed positions, where overlay digits 0-9 are
+// sent as a-j.
+func compressedTableChar(c byte) byte {
+	if c >= '0' && c <= '9' {
+		return c - '0' + 'a'
+	}
+	return c
+}
+
+// encodeBase91 encodes v as a base-91 string of n characters.
+func encodeBase91(v, n int) string {
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(v%91 + 33)
+		v /= 91
+	}
+	return string(b)
+}
+
+// EncodeCompressedPosition creates a compressed (base-91) APRS position string.
+// Arguments are as for EncodePosition. The two cs bytes can carry only one of
+// course/speed, altitude or radio range; course/speed takes precedence, and
+// an altitude that does not fit there is appended to the comment as /A=NNNNNN.
+// Ambiguity and DAO options are ignored, the compressed format has a fixed
+// resolution of about 0.3 meters.
+func EncodeCompressedPosition(lat, lon float64, speed, course, altitude *float64, symbol string, opts *EncodePositionOpts) (string, error) {
+	if opts == nil {
+		opts = &EncodePositionOpts{}
+	}
+
+	body, err := encodeCompressedCoords(lat, lon, speed, course, altitude, symbol, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return positionPrefix(opts) + body, nil
+}
+
+// encodeCompressedCoords creates the compressed position data, without the
+// data type identifier, followed by the comment.
+func encodeCompressedCoords(lat, lon float64, speed, course, altitude *float64, symbol string, opts *EncodePositionOpts) (string, error) {
+	// Validate coordinates
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return "", &ParseError{Code: ErrPosEncInvalid.Code, Msg: fmt.Sprintf("invalid coordinates: lat=%f lon=%f", lat, lon)}
+	}
+
+	symbolTable, symbolCode, err := parseEncodeSymbol(symbol)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.NMEASource < CompressionSourceOther || opts.NMEASource > CompressionSourceRMC {
+		return "", &ParseError{Code: ErrPosEncInvalid.Code, Msg: fmt.Sprintf("invalid compression NMEA source: %d", opts.NMEASource)}
+	}
+	if opts.Origin < CompressionOriginCompressed || opts.Origin > CompressionOriginDigipeater {
+		return "", &ParseError{Code: ErrPosEncInvalid.Code, Msg: fmt.Sprintf("invalid compression origin: %d", opts.Origin)}
+	}
+
+	// Latitude and longitude, 4 base-91 digits each
+	y := int(math.Round(380926.0 * (90.0 - lat)))
+	x := int(math.Round(190463.0 * (180.0 + lon)))
+	const maxVal = 91*91*91*91 - 1
+	y = min(max(y, 0), maxVal)
+	x = min(max(x, 0), maxVal)
+
+	source := opts.NMEASource
+	extra := ""
+	cs := "  "
+
+	switch {
+	case speed != nil && course != nil && *speed >= 0 && *course >= 0:
+		// Course/speed: c = course / 4, s = log1.08(knots + 1)
+		c := int(math.Round(*course/4)) % 90
+		s := int(math.Round(math.Log(*speed/1.852+1) / math.Log(1.08)))
+		s = min(s, 90)
+		cs = string([]byte{byte(c + 33), byte(s + 33)})
+		if source == CompressionSourceGGA {
+			// GGA source would make the parser read cs as altitude
+			source = CompressionSourceOther
+		}
+		if altitude != nil {
+			extra = encodeAltitudeComment(*altitude)
+		}
+	case altitude != nil && *altitude/0.3048 < 1:
+		// Altitudes below one foot cannot be compressed
+		extra = encodeAltitudeComment(*altitude)
+	case altitude != nil:
+		// Altitude: cs = log1.002(feet), only possible with GGA source
+		v := int(math.Round(math.Log(*altitude/0.3048) / math.Log(1.002)))
+		cs = encodeBase91(min(v, 91*91-1), 2)
+		source = CompressionSourceGGA
+	case opts.RadioRange != nil && *opts.RadioRange > 0:
+		// Radio range: s = log1.08(miles / 2)
+		miles := *opts.RadioRange / 1.609344
+		s := int(math.Round(math.Log(miles/2) / math.Log(1.08)))
+		s = min(max(s, 0), 90)
+		cs = "{" + string(byte(s+33))
+		if source == CompressionSourceGGA {
+			source = CompressionSourceOther
+		}
+	}
+
+	compType := int(opts.Origin) | int(source)<<3
+	if opts.GPSFix {
+		compType |= 0x20
+	}
+
+	result := string(compressedTableChar(symbolTable)) + encodeBase91(y, 4) + encodeBase91(x, 4) +
+		string(symbolCode) + cs + string(byte(compType+33)) + extra + opts.Comment
+
+	return result, nil
+}
+
+// encodeAltitudeComment formats an altitude in meters as a /A=NNNNNN comment
+// extension, in feet.
+func encodeAltitudeComment(altitude float64) string {
+	altFeet := altitude / 0.3048
+	if altFeet >= 0 {
+		return fmt.Sprintf("/A=%06.0f", altFeet)
+	}
+	return fmt.Sprintf("/A=-%05.0f", -altFeet)
+}
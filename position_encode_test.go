@@ -2,6 +2,7 @@ package fap
 
 import (
 	"errors"
+	"math"
 	"testing"
 	"time"
 )
@@ -148,3 +149,179 @@ func TestEncodePositionErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeCompressedPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lon float64
+		speed    *float64
+		course   *float64
+		altitude *float64
+		symbol   string
+		opts     *EncodePositionOpts
+		want     string
+	}{
+		{
+			"APRS101 course/speed example",
+			49.5, -72.75, new(36.2 * 1.852), new(88.0), nil, "/>",
+			&EncodePositionOpts{GPSFix: true, NMEASource: CompressionSourceRMC, Origin: CompressionOriginSoftware},
+			"!/5L!!<*e8>7P[",
+		},
+		{
+			"APRS101 altitude example",
+			49.5, -72.75, nil, nil, new(10004 * 0.3048), "/>", nil,
+			"!/5L!!<*e8>S]1",
+		},
+		{
+			"APRS101 radio range example",
+			49.5, -72.75, nil, nil, nil, "/>", &EncodePositionOpts{RadioRange: new(20 * 1.609344)},
+			"!/5L!!<*e8>{?!",
+		},
+		{
+			"no course/speed/alt, overlay and comment",
+			60.3582, 24.8084, nil, nil, nil, "3#", &EncodePositionOpts{Comment: "hi"},
+			"!d/zPUTfVz#  !hi",
+		},
+		{
+			"course/speed and altitude",
+			49.5, -72.75, new(36.2 * 1.852), new(88.0), new(95.7072), "/>", nil,
+			"!/5L!!<*e8>7P!/A=000314",
+		},
+		{
+			"with timestamp and messaging",
+			49.5, -72.75, nil, nil, nil, "/>",
+			&EncodePositionOpts{MessagingCapable: true, Timestamp: time.Date(2024, 3, 15, 12, 30, 45, 0, time.UTC)},
+			"@123045h/5L!!<*e8>  !",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := EncodeCompressedPosition(tc.lat, tc.lon, tc.speed, tc.course, tc.altitude, tc.symbol, tc.opts)
+			if err != nil {
+				t.Fatalf("EncodeCompressedPosition failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEncodeCompressedPositionRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		lat, lon   float64
+		speed      *float64
+		course     *float64
+		altitude   *float64
+		radioRange *float64
+	}{
+		{"stationary", 60.1234, 24.5678, nil, nil, nil, nil},
+		{"south west", -33.8688, -151.2093, nil, nil, nil, nil},
+		{"moving", 52.364, 14.1045, new(83.34), new(353.0), nil, nil},
+		{"altitude", 46.5, 7.95, nil, nil, new(3454.0), nil},
+		{"altitude with course/speed", 46.5, 7.95, new(20.0), new(90.0), new(3454.0), nil},
+		{"radio range", 63.06716666666667, 27.6605, nil, nil, nil, new(50.0)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := EncodeCompressedPosition(tc.lat, tc.lon, tc.speed, tc.course, tc.altitude, "/>",
+				&EncodePositionOpts{RadioRange: tc.radioRange, GPSFix: true, Comment: " test"})
+			if err != nil {
+				t.Fatalf("EncodeCompressedPosition failed: %v", err)
+			}
+			p, err := Parse("N0CALL>APRS:" + body)
+			if err != nil {
+				t.Fatalf("failed to parse %q: %v", body, err)
+			}
+			if p.Format != FormatCompressed {
+				t.Errorf("format = %q, want %q", p.Format, FormatCompressed)
+			}
+			if math.Abs(*p.Latitude-tc.lat) > 0.00001 {
+				t.Errorf("latitude = %f, want %f", *p.Latitude, tc.lat)
+			}
+			if math.Abs(*p.Longitude-tc.lon) > 0.00001 {
+				t.Errorf("longitude = %f, want %f", *p.Longitude, tc.lon)
+			}
+			if p.Comment != "test" {
+				t.Errorf("comment = %q, want %q", p.Comment, "test")
+			}
+			if tc.speed != nil {
+				// Speed resolution is 8% of the value, course resolution 4 degrees
+				if p.Speed == nil || math.Abs(*p.Speed-*tc.speed) > *tc.speed*0.04+1 {
+					t.Errorf("speed = %v, want %f", p.Speed, *tc.speed)
+				}
+				if p.Course == nil || math.Abs(float64(*p.Course)-*tc.course) > 2 {
+					t.Errorf("course = %v, want %f", p.Course, *tc.course)
+				}
+			} else if p.Speed != nil || p.Course != nil {
+				t.Errorf("speed/course = %v/%v, want nil", p.Speed, p.Course)
+			}
+			if tc.altitude != nil {
+				// Compressed altitude resolution is 0.2% of the value
+				if p.Altitude == nil || math.Abs(*p.Altitude-*tc.altitude) > *tc.altitude*0.002 {
+					t.Errorf("altitude = %v, want %f", p.Altitude, *tc.altitude)
+				}
+			} else if p.Altitude != nil {
+				t.Errorf("altitude = %f, want nil", *p.Altitude)
+			}
+			if tc.radioRange != nil {
+				if p.RadioRange == nil || math.Abs(*p.RadioRange-*tc.radioRange) > *tc.radioRange*0.04 {
+					t.Errorf("radio range = %v, want %f", p.RadioRange, *tc.radioRange)
+				}
+			} else if p.RadioRange != nil {
+				t.Errorf("radio range = %f, want nil", *p.RadioRange)
+			}
+			if tc.speed != nil || tc.altitude != nil || tc.radioRange != nil {
+				if p.GPSFixStatus == nil || *p.GPSFixStatus != 1 {
+					t.Errorf("gps fix status = %v, want 1", p.GPSFixStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeCompressedPositionOverlay(t *testing.T) {
+	body, err := EncodeCompressedPosition(60.0, 25.0, nil, nil, nil, "5#", nil)
+	if err != nil {
+		t.Fatalf("EncodeCompressedPosition failed: %v", err)
+	}
+	if body[1] != 'f' {
+		t.Errorf("compressed table char = %c, want f", body[1])
+	}
+	p, err := Parse("N0CALL>APRS:" + body)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", body, err)
+	}
+	if p.SymbolTable != '5' || p.SymbolCode != '#' {
+		t.Errorf("symbol = %c%c, want 5#", p.SymbolTable, p.SymbolCode)
+	}
+}
+
+func TestEncodeCompressedPositionErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		lat    float64
+		lon    float64
+		symbol string
+		opts   *EncodePositionOpts
+	}{
+		{"lat too high", 91.0, 0.0, "/#", nil},
+		{"lon too low", 0.0, -181.0, "/#", nil},
+		{"invalid symbol table", 0.0, 0.0, "a#", nil},
+		{"symbol too short", 0.0, 0.0, "/", nil},
+		{"invalid NMEA source", 0.0, 0.0, "/#", &EncodePositionOpts{NMEASource: 4}},
+		{"invalid origin", 0.0, 0.0, "/#", &EncodePositionOpts{Origin: 8}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := EncodeCompressedPosition(tc.lat, tc.lon, nil, nil, nil, tc.symbol, tc.opts)
+			if !errors.Is(err, ErrPosEncInvalid) {
+				t.Errorf("expected ErrPosEncInvalid, got %v", err)
+			}
+		})
+	}
+}
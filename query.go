@@ -0,0 +1,180 @@
+package fap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// directedQueries maps the message text of directed queries to query types.
+var directedQueries = map[string]QueryType{
+	"?APRSP": QueryPosition,
+	"?APRSS": QueryStatus,
+	"?APRST": QueryTrace,
+	"?PING?": QueryPing,
+	"?APRSM": QueryMessages,
+	"?APRSD": QueryDirectHeard,
+	"?APRSO": QueryObjects,
+}
+
+// parseQuery parses a general query.
+// Format: ?QUERY?  or  ?QUERY? lat,lon,radius
+// The footprint radius is in miles. Returns false if the body is not a
+// query.
+func (p *Packet) parseQuery() bool {
+	body := p.Body[1:] // skip '?'
+
+	end := strings.IndexByte(body, '?')
+	if end < 1 || end > 9 {
+		return false
+	}
+	name := body[:end]
+	for i := 0; i < len(name); i++ {
+		if (name[i] < 'A' || name[i] > 'Z') && (name[i] < '0' || name[i] > '9') {
+			return false
+		}
+	}
+
+	p.Type = PacketTypeQuery
+	q := &Query{Type: QueryType(name)}
+	p.Query = q
+
+	footprint := strings.TrimSpace(body[end+1:])
+	if footprint == "" {
+		return true
+	}
+
+	parts := strings.Split(footprint, ",")
+	if len(parts) != 3 {
+		p.warn(ErrQueryInvalid, fmt.Sprintf("invalid query footprint: %q", footprint))
+		return true
+	}
+	lat, laterr := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lon, lonerr := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	radius, raderr := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+	if laterr != nil || lonerr != nil || raderr != nil ||
+		lat < -90 || lat > 90 || lon < -180 || lon > 180 || radius < 0 {
+		p.warn(ErrQueryInvalid, fmt.Sprintf("invalid query footprint: %q", footprint))
+		return true
+	}
+
+	radiusKm := radius * 1.609344 // miles to km
+	q.Latitude = &lat
+	q.Longitude = &lon
+	q.Radius = &radiusKm
+
+	return true
+}
+
+// QueryResponder answers queries on behalf of a station, using the
+// station's current state. Fields may be updated between calls, but a
+// QueryResponder must not be modified while Respond is running.
+type QueryResponder struct {
+	Callsign  string  // Callsign of the station, directed queries to other stations are ignored
+	Latitude  float64 // Position of the station
+	Longitude float64
+	Symbol    string // 2-character symbol (table + code)
+
+	PositionOpts EncodePositionOpts // Options for position reports, including the comment
+	Compressed   bool               // Send compressed position reports
+
+	Status string // Status text, ?APRSS is not answered if empty
+
+	// Weather is the current weather, sent in reply to ?WX?. If nil, ?WX?
+	// is not answered. The report has the symbol of the station if it is
+	// a weather symbol (code '_'), and the weather symbol /_ otherwise.
+	Weather *Weather
+
+	IGate    bool // Answer ?IGATE? with capabilities
+	MsgCount int  // IGate capability MSG_CNT: number of messages gated to RF
+	LocCount int  // IGate capability LOC_CNT: number of local stations
+
+	// PendingMessages returns the outstanding messages to a station for
+	// ?APRSM. If nil, ?APRSM is not answered.
+	PendingMessages func(callsign string) []*Message
+}
+
+// Respond returns the bodies of the packets the station should transmit
+// in reply to a query packet, or nil if the packet is not a query the
+// station should answer. General queries with a footprint are only
+// answered if the station is within it.
+func (r *QueryResponder) Respond(p *Packet) ([]string, error) {
+	q := p.Query
+	if p.Type != PacketTypeQuery || q == nil {
+		return nil, nil
+	}
+
+	if q.Directed {
+		if p.Message == nil || !strings.EqualFold(p.Message.Destination, r.Callsign) {
+			return nil, nil
+		}
+	} else if q.Radius != nil && Distance(*q.Latitude, *q.Longitude, r.Latitude, r.Longitude) > *q.Radius {
+		return nil, nil
+	}
+
+	switch q.Type {
+	case QueryAPRS, QueryPosition:
+		return r.single(r.position())
+
+	case QueryWx:
+		if r.Weather == nil {
+			return nil, nil
+		}
+		symbol := r.Symbol
+		if len(symbol) != 2 || symbol[1] != '_' {
+			symbol = "/_"
+		}
+		return r.single(EncodeWeather(r.Latitude, r.Longitude, r.Weather, symbol, &EncodeWeatherOpts{
+			EncodePositionOpts: r.PositionOpts,
+			Compressed:         r.Compressed,
+		}))
+
+	case QueryIGate:
+		if !r.IGate {
+			return nil, nil
+		}
+		return []string{fmt.Sprintf("<IGATE,MSG_CNT=%d,LOC_CNT=%d", r.MsgCount, r.LocCount)}, nil
+
+	case QueryStatus:
+		if r.Status == "" {
+			return nil, nil
+		}
+		return r.single(EncodeStatus(r.Status, nil))
+
+	case QueryTrace, QueryPing:
+		// The route the query took is returned to the querying station
+		return r.single(EncodeMessage(&Message{Destination: p.SrcCallsign, Text: p.Header}))
+
+	case QueryMessages:
+		if r.PendingMessages == nil {
+			return nil, nil
+		}
+		var bodies []string
+		for _, msg := range r.PendingMessages(p.SrcCallsign) {
+			body, err := EncodeMessage(msg)
+			if err != nil {
+				return nil, err
+			}
+			bodies = append(bodies, body)
+		}
+		return bodies, nil
+	}
+
+	return nil, nil
+}
+
+// position encodes the position report of the station.
+func (r *QueryResponder) position() (string, error) {
+	if r.Compressed {
+		return EncodeCompressedPosition(r.Latitude, r.Longitude, nil, nil, nil, r.Symbol, &r.PositionOpts)
+	}
+	return EncodePosition(r.Latitude, r.Longitude, nil, nil, nil, r.Symbol, &r.PositionOpts)
+}
+
+// single wraps the result of an encoder in a slice.
+func (r *QueryResponder) single(body string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	return []string{body}, nil
+}
@@ -0,0 +1,231 @@
+package fap
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGeneralQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		packet     string
+		wantType   QueryType
+		wantFoot   bool
+		wantLat    float64
+		wantLon    float64
+		wantRadius float64
+	}{
+		{"aprs", "N0CALL>APRS:?APRS?", QueryAPRS, false, 0, 0, 0},
+		{"igate", "N0CALL>APRS:?IGATE?", QueryIGate, false, 0, 0, 0},
+		{"wx with footprint", "N0CALL>APRS:?WX? 34.02,-117.15,0200", QueryWx, true, 34.02, -117.15, 200 * 1.609344},
+		{"footprint without space", "N0CALL>APRS:?APRS?60.1,24.9,50", QueryAPRS, true, 60.1, 24.9, 50 * 1.609344},
+		{"unknown query", "N0CALL>APRS:?DGPS?", QueryType("DGPS"), false, 0, 0, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Parse(tc.packet)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if p.Type != PacketTypeQuery {
+				t.Errorf("type = %q, want %q", p.Type, PacketTypeQuery)
+			}
+			q := p.Query
+			if q == nil {
+				t.Fatal("query is nil")
+			}
+			if q.Type != tc.wantType || q.Directed {
+				t.Errorf("query = %s directed %v, want %s general", q.Type, q.Directed, tc.wantType)
+			}
+			if !tc.wantFoot {
+				if q.Latitude != nil || q.Longitude != nil || q.Radius != nil {
+					t.Errorf("footprint = %v/%v/%v, want nil", q.Latitude, q.Longitude, q.Radius)
+				}
+				return
+			}
+			if q.Latitude == nil || *q.Latitude != tc.wantLat || *q.Longitude != tc.wantLon {
+				t.Errorf("footprint centre = %v/%v, want %f/%f", q.Latitude, q.Longitude, tc.wantLat, tc.wantLon)
+			}
+			if q.Radius == nil || math.Abs(*q.Radius-tc.wantRadius) > 1e-9 {
+				t.Errorf("radius = %v, want %f", q.Radius, tc.wantRadius)
+			}
+		})
+	}
+}
+
+func TestGeneralQueryInvalidFootprint(t *testing.T) {
+	p, err := Parse("N0CALL>APRS:?APRS? 34.02,-117.15")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if p.Query == nil || p.Query.Type != QueryAPRS {
+		t.Fatalf("query = %+v, want APRS", p.Query)
+	}
+	if p.Query.Radius != nil {
+		t.Errorf("radius = %f, want nil", *p.Query.Radius)
+	}
+	if len(p.Warnings) != 1 || !errors.Is(&p.Warnings[0], ErrQueryInvalid) {
+		t.Errorf("warnings = %v, want %v", p.Warnings, ErrQueryInvalid)
+	}
+}
+
+func TestNotQuery(t *testing.T) {
+	p, _ := Parse("N0CALL>APRS:?aprs?")
+	if p.Type == PacketTypeQuery {
+		t.Errorf("lowercase query parsed as query")
+	}
+	p, _ = Parse("N0CALL>APRS:?APRS")
+	if p.Type == PacketTypeQuery {
+		t.Errorf("unterminated query parsed as query")
+	}
+}
+
+func TestDirectedQuery(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantType QueryType
+	}{
+		{"?APRSP", QueryPosition},
+		{"?APRSS", QueryStatus},
+		{"?APRST", QueryTrace},
+		{"?PING?", QueryPing},
+		{"?APRSM", QueryMessages},
+		{"?APRSD", QueryDirectHeard},
+		{"?APRSO", QueryObjects},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			p, err := Parse("N0CALL>APRS::OH7LZB   :" + tc.text)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if p.Type != PacketTypeQuery {
+				t.Errorf("type = %q, want %q", p.Type, PacketTypeQuery)
+			}
+			if p.Query == nil || p.Query.Type != tc.wantType || !p.Query.Directed {
+				t.Errorf("query = %+v, want directed %s", p.Query, tc.wantType)
+			}
+			if p.Message == nil || p.Message.Destination != "OH7LZB" {
+				t.Errorf("message = %+v, want destination OH7LZB", p.Message)
+			}
+		})
+	}
+
+	p, _ := Parse("N0CALL>APRS::OH7LZB   :?APRSX")
+	if p.Type != PacketTypeMessage || p.Query != nil {
+		t.Errorf("unknown directed query: type = %q, query = %+v", p.Type, p.Query)
+	}
+}
+
+func TestQueryResponder(t *testing.T) {
+	r := &QueryResponder{
+		Callsign:     "OH7LZB-1",
+		Latitude:     60.5,
+		Longitude:    24.75,
+		Symbol:       "/_",
+		PositionOpts: EncodePositionOpts{MessagingCapable: true, Comment: "Home"},
+		Status:       "On the air",
+		Weather:      &Weather{Temp: new(20.0)},
+		IGate:        true,
+		MsgCount:     3,
+		LocCount:     12,
+		PendingMessages: func(callsign string) []*Message {
+			if callsign != "N0CALL" {
+				return nil
+			}
+			return []*Message{{Destination: "N0CALL", Text: "Hello", ID: "1"}}
+		},
+	}
+
+	tests := []struct {
+		name   string
+		packet string
+		want   []string
+	}{
+		{"general position", "N0CALL>APRS:?APRS?", []string{"=6030.00N/02445.00E_Home"}},
+		{"inside footprint", "N0CALL>APRS:?APRS? 60.4,24.8,10", []string{"=6030.00N/02445.00E_Home"}},
+		{"outside footprint", "N0CALL>APRS:?APRS? 61.5,24.8,10", nil},
+		{"igate", "N0CALL>APRS:?IGATE?", []string{"<IGATE,MSG_CNT=3,LOC_CNT=12"}},
+		{"weather", "N0CALL>APRS:?WX?", []string{"=6030.00N/02445.00E_.../...g...t068Home"}},
+		{"directed position", "N0CALL>APRS::OH7LZB-1 :?APRSP", []string{"=6030.00N/02445.00E_Home"}},
+		{"directed to another station", "N0CALL>APRS::OH7LZB-2 :?APRSP", nil},
+		{"status", "N0CALL>APRS::oh7lzb-1 :?APRSS", []string{">On the air"}},
+		{"trace", "N0CALL>APRS,WIDE1-1*,qAR,OH2IGT::OH7LZB-1 :?APRST", []string{":N0CALL   :N0CALL>APRS,WIDE1-1*,qAR,OH2IGT"}},
+		{"ping", "N0CALL>APRS::OH7LZB-1 :?PING?", []string{":N0CALL   :N0CALL>APRS"}},
+		{"messages", "N0CALL>APRS::OH7LZB-1 :?APRSM", []string{":N0CALL   :Hello{1"}},
+		{"unsupported", "N0CALL>APRS::OH7LZB-1 :?APRSD", nil},
+		{"not a query", "N0CALL>APRS:>status", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Parse(tc.packet)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			got, err := r.Respond(p)
+			if err != nil {
+				t.Fatalf("Respond failed: %v", err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") || len(got) != len(tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQueryResponderWeatherSymbol(t *testing.T) {
+	p, err := Parse("N0CALL>APRS:?WX?")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	// A station which is not a weather station reports with /_
+	r := &QueryResponder{Callsign: "OH7LZB", Latitude: 60.5, Longitude: 24.75, Symbol: "/-",
+		Weather: &Weather{Temp: new(20.0)}}
+	for _, tc := range []struct{ symbol, want string }{{"/-", "/_"}, {"\\_", "\\_"}} {
+		r.Symbol = tc.symbol
+		got, err := r.Respond(p)
+		if err != nil || len(got) != 1 {
+			t.Fatalf("symbol %s: got %q, %v, want one reply", tc.symbol, got, err)
+		}
+		wx, err := Parse("OH7LZB>APRS:" + got[0])
+		if err != nil {
+			t.Fatalf("failed to parse reply %q: %v", got[0], err)
+		}
+		if symbol := string([]byte{wx.SymbolTable, wx.SymbolCode}); symbol != tc.want {
+			t.Errorf("symbol %s: reply symbol = %q, want %q", tc.symbol, symbol, tc.want)
+		}
+	}
+}
+
+func TestQueryResponderDisabled(t *testing.T) {
+	r := &QueryResponder{Callsign: "OH7LZB", Latitude: 60.5, Longitude: 24.75, Symbol: "/-"}
+
+	for _, packet := range []string{
+		"N0CALL>APRS:?IGATE?",
+		"N0CALL>APRS:?WX?",
+		"N0CALL>APRS::OH7LZB   :?APRSS",
+		"N0CALL>APRS::OH7LZB   :?APRSM",
+	} {
+		p, err := Parse(packet)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", packet, err)
+		}
+		got, err := r.Respond(p)
+		if err != nil || got != nil {
+			t.Errorf("%q: got %q, %v, want no reply", packet, got, err)
+		}
+	}
+
+	// Encoding errors are returned
+	r.Symbol = "xx"
+	p, _ := Parse("N0CALL>APRS:?APRS?")
+	if _, err := r.Respond(p); !errors.Is(err, ErrPosEncInvalid) {
+		t.Errorf("expected %v, got %v", ErrPosEncInvalid, err)
+	}
+}
@@ -3,15 +3,85 @@ package fap
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
+// Maximum status text lengths
+const (
+	statusMaxLen          = 62 // without a timestamp
+	statusTimestampMaxLen = 55 // with a DDHHMMz timestamp
+	statusLocatorMaxLen   = 53 // after a Maidenhead locator and symbol
+)
+
+// EncodeStatusOpts contains optional parameters for EncodeStatus.
+type EncodeStatusOpts struct {
+	Timestamp time.Time // if non-zero, include DDHHMMz UTC timestamp
+	Locator   string    // 4 or 6 character Maidenhead grid locator, e.g. "IO91SX"
+	Symbol    string    // 2-character symbol (table + code), required with Locator
+}
+
+// EncodeStatus creates an APRS status report body. The status text may
+// be preceded by either a timestamp or a Maidenhead grid locator and
+// symbol, but not both.
+func EncodeStatus(text string, opts *EncodeStatusOpts) (string, error) {
+	if opts == nil {
+		opts = &EncodeStatusOpts{}
+	}
+
+	if !isPrintableASCII(text) || strings.ContainsAny(text, "|~") {
+		return "", &ParseError{Code: ErrStatusEncInvalid.Code, Msg: "status text must be printable ASCII without | or ~"}
+	}
+
+	switch {
+	case opts.Locator != "":
+		if !opts.Timestamp.IsZero() {
+			return "", &ParseError{Code: ErrStatusEncInvalid.Code, Msg: "status cannot have both a timestamp and a locator"}
+		}
+		if _, _, _, ok := parseMaidenhead(opts.Locator); !ok {
+			return "", &ParseError{Code: ErrStatusEncInvalid.Code, Msg: fmt.Sprintf("invalid Maidenhead locator: %q", opts.Locator)}
+		}
+		table, code, err := parseEncodeSymbol(opts.Symbol)
+		if err != nil {
+			return "", err
+		}
+		if len(text) > statusLocatorMaxLen {
+			return "", &ParseError{Code: ErrStatusEncInvalid.Code, Msg: fmt.Sprintf("status text too long: %d characters (max %d with a locator)", len(text), statusLocatorMaxLen)}
+		}
+		body := ">" + opts.Locator + string([]byte{table, code})
+		if text != "" {
+			body += " " + text
+		}
+		return body, nil
+
+	case !opts.Timestamp.IsZero():
+		if len(text) > statusTimestampMaxLen {
+			return "", &ParseError{Code: ErrStatusEncInvalid.Code, Msg: fmt.Sprintf("status text too long: %d characters (max %d with a timestamp)", len(text), statusTimestampMaxLen)}
+		}
+		ts := opts.Timestamp.UTC()
+		return fmt.Sprintf(">%02d%02d%02dz%s", ts.Day(), ts.Hour(), ts.Minute(), text), nil
+
+	default:
+		if len(text) > statusMaxLen {
+			return "", &ParseError{Code: ErrStatusEncInvalid.Code, Msg: fmt.Sprintf("status text too long: %d characters (max %d)", len(text), statusMaxLen)}
+		}
+		return ">" + text, nil
+	}
+}
+
 // parseStatus parses an APRS status report.
-// Format: >DDHHMMzstatus text  or  >status text
+// Format: >DDHHMMzstatus text  or  >GGggss/$ status text  or  >status text
 func (p *Packet) parseStatus(opt *options) error {
 	p.Type = PacketTypeStatus
 
 	body := p.Body[1:] // skip '>'
 
+	// Maidenhead locator beacon. A locator starts with letters, so it
+	// cannot be confused with a timestamp.
+	if text, ok := p.parseStatusLocator(body); ok {
+		p.Status = text
+		return nil
+	}
+
 	// Check if body starts with a timestamp (7 chars ending in z, h, or /)
 	if len(body) >= 7 {
 		indicator := body[6]
@@ -31,6 +101,77 @@ func (p *Packet) parseStatus(opt *options) error {
 	return nil
 }
 
+// parseStatusLocator parses a Maidenhead grid locator and symbol at the
+// start of a status report, or a 6-character locator alone, and sets the
+// position to the centre of the grid square. Returns the status text following them, and false if the
+// body does not start with a locator.
+func (p *Packet) parseStatusLocator(body string) (string, bool) {
+	// A 6-character locator alone has no symbol, although it looks like a
+	// 4-character locator followed by an overlay symbol
+	if len(body) == 6 {
+		if lat, lon, res, ok := parseMaidenhead(body); ok {
+			p.Latitude = &lat
+			p.Longitude = &lon
+			p.PosResolution = &res
+			return "", true
+		}
+	}
+
+	// 6-character locators are tried first, for the same reason
+	for _, n := range []int{6, 4} {
+		if len(body) < n+2 || (len(body) > n+2 && body[n+2] != ' ') {
+			continue
+		}
+		lat, lon, res, ok := parseMaidenhead(body[:n])
+		if !ok || !isValidSymbolTable(body[n]) || body[n+1] < 0x21 || body[n+1] > 0x7e {
+			continue
+		}
+
+		p.Latitude = &lat
+		p.Longitude = &lon
+		p.PosResolution = &res
+		p.SymbolTable = body[n]
+		p.SymbolCode = body[n+1]
+
+		if len(body) > n+2 {
+			return body[n+3:], true
+		}
+		return "", true
+	}
+	return "", false
+}
+
+// parseMaidenhead converts a 4 or 6 character Maidenhead grid locator to
+// the latitude and longitude of the centre of the grid square, and the
+// position resolution in meters (the height of the square).
+func parseMaidenhead(loc string) (lat, lon, res float64, ok bool) {
+	if len(loc) != 4 && len(loc) != 6 {
+		return 0, 0, 0, false
+	}
+	if loc[0] < 'A' || loc[0] > 'R' || loc[1] < 'A' || loc[1] > 'R' ||
+		loc[2] < '0' || loc[2] > '9' || loc[3] < '0' || loc[3] > '9' {
+		return 0, 0, 0, false
+	}
+
+	// Field: 20 x 10 degrees, square: 2 x 1 degrees
+	lon = float64(loc[0]-'A')*20 + float64(loc[2]-'0')*2 - 180
+	lat = float64(loc[1]-'A')*10 + float64(loc[3]-'0') - 90
+
+	if len(loc) == 4 {
+		return lat + 0.5, lon + 1, 1852.0 * 60, true
+	}
+
+	// Subsquare: 5 x 2.5 minutes, letters in either case
+	sx := loc[4] | 0x20
+	sy := loc[5] | 0x20
+	if sx < 'a' || sx > 'x' || sy < 'a' || sy > 'x' {
+		return 0, 0, 0, false
+	}
+	lon += float64(sx-'a')*5/60 + 2.5/60
+	lat += float64(sy-'a')*2.5/60 + 1.25/60
+	return lat, lon, 1852.0 * 2.5, true
+}
+
 // parseCapabilities parses an APRS station capabilities packet.
 // Format: <cap1=val1,cap2=val2,...
 func (p *Packet) parseCapabilities(opt *options) error {
@@ -1,7 +1,9 @@
 package fap
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 )
@@ -59,3 +61,148 @@ func TestStatusWithTimestamp(t *testing.T) {
 		t.Errorf("status = %q, want %q", p.Status, msg)
 	}
 }
+
+func TestStatusMaidenhead(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantLat    float64
+		wantLon    float64
+		wantRes    float64
+		wantSymbol string
+		wantStatus string
+	}{
+		{"6-character locator", ">IO91SX/G My house", 51.979167, -0.458333, 4630, "/G", "My house"},
+		{"lowercase subsquare", ">IO91sx/G My house", 51.979167, -0.458333, 4630, "/G", "My house"},
+		{"4-character locator", ">IO91/G My house", 51.5, -1, 111120, "/G", "My house"},
+		{"overlay symbol, no text", ">FN42A#", 42.5, -71, 111120, "A#", ""},
+		{"southern hemisphere", ">QF22LE/- Home", -37.8125, 144.958333, 4630, "/-", "Home"},
+		{"6-character locator without symbol", ">IO91SX", 51.979167, -0.458333, 4630, "", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Parse("N0CALL>APRS:" + tc.body)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if p.Type != PacketTypeStatus {
+				t.Errorf("type = %q, want %q", p.Type, PacketTypeStatus)
+			}
+			if p.Latitude == nil || p.Longitude == nil || p.PosResolution == nil {
+				t.Fatal("position not set")
+			}
+			if math.Abs(*p.Latitude-tc.wantLat) > 0.00001 {
+				t.Errorf("latitude = %f, want %f", *p.Latitude, tc.wantLat)
+			}
+			if math.Abs(*p.Longitude-tc.wantLon) > 0.00001 {
+				t.Errorf("longitude = %f, want %f", *p.Longitude, tc.wantLon)
+			}
+			if *p.PosResolution != tc.wantRes {
+				t.Errorf("posresolution = %f, want %f", *p.PosResolution, tc.wantRes)
+			}
+			symbol := ""
+			if p.SymbolTable != 0 {
+				symbol = string([]byte{p.SymbolTable, p.SymbolCode})
+			}
+			if symbol != tc.wantSymbol {
+				t.Errorf("symbol = %q, want %q", symbol, tc.wantSymbol)
+			}
+			if p.Status != tc.wantStatus {
+				t.Errorf("status = %q, want %q", p.Status, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestStatusNotMaidenhead(t *testing.T) {
+	for _, body := range []string{
+		">Nashville,TN>>Toronto,ON",
+		">IO91SX/Gtext",
+		">SZ91/G text",
+		">IO9A/G text",
+		">IO91SY/",
+		">092345zIO91SX/G text",
+	} {
+		p, err := Parse("N0CALL>APRS:" + body)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", body, err)
+		}
+		if p.Latitude != nil {
+			t.Errorf("%q: latitude = %f, want nil", body, *p.Latitude)
+		}
+	}
+}
+
+func TestEncodeStatus(t *testing.T) {
+	ts := time.Date(2025, 1, 9, 23, 45, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		text string
+		opts *EncodeStatusOpts
+		want string
+	}{
+		{"plain", "Net control", nil, ">Net control"},
+		{"timestamp", "Net control", &EncodeStatusOpts{Timestamp: ts}, ">092345zNet control"},
+		{"locator", "My house", &EncodeStatusOpts{Locator: "IO91SX", Symbol: "/G"}, ">IO91SX/G My house"},
+		{"locator without text", "", &EncodeStatusOpts{Locator: "IO91", Symbol: "/G"}, ">IO91/G"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := EncodeStatus(tc.text, tc.opts)
+			if err != nil {
+				t.Fatalf("EncodeStatus failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+
+			p, err := Parse("N0CALL>APRS:" + got)
+			if err != nil {
+				t.Fatalf("failed to parse %q: %v", got, err)
+			}
+			if p.Status != tc.text {
+				t.Errorf("status = %q, want %q", p.Status, tc.text)
+			}
+		})
+	}
+}
+
+func TestEncodeStatusErrors(t *testing.T) {
+	ts := time.Date(2025, 1, 9, 23, 45, 0, 0, time.UTC)
+	long := fmt.Sprintf("%063d", 0)
+	tests := []struct {
+		name    string
+		text    string
+		opts    *EncodeStatusOpts
+		wantErr error
+	}{
+		{"too long", long, nil, ErrStatusEncInvalid},
+		{"too long with timestamp", long[:56], &EncodeStatusOpts{Timestamp: ts}, ErrStatusEncInvalid},
+		{"too long with locator", long[:54], &EncodeStatusOpts{Locator: "IO91", Symbol: "/G"}, ErrStatusEncInvalid},
+		{"pipe", "a|b", nil, ErrStatusEncInvalid},
+		{"tilde", "a~b", nil, ErrStatusEncInvalid},
+		{"control character", "a\nb", nil, ErrStatusEncInvalid},
+		{"timestamp and locator", "x", &EncodeStatusOpts{Timestamp: ts, Locator: "IO91", Symbol: "/G"}, ErrStatusEncInvalid},
+		{"invalid locator", "x", &EncodeStatusOpts{Locator: "IO9", Symbol: "/G"}, ErrStatusEncInvalid},
+		{"missing symbol", "x", &EncodeStatusOpts{Locator: "IO91"}, ErrPosEncInvalid},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := EncodeStatus(tc.text, tc.opts)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	// Maximum lengths are accepted
+	if _, err := EncodeStatus(long[:62], nil); err != nil {
+		t.Errorf("62 characters: %v", err)
+	}
+	if _, err := EncodeStatus(long[:55], &EncodeStatusOpts{Timestamp: ts}); err != nil {
+		t.Errorf("55 characters with timestamp: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package fap
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -36,6 +37,19 @@ func isNumericValue(s string) bool {
 	return i == len(s) && i > start
 }
 
+// isTelemetryBits checks that s is an 8-character string of 0 and 1.
+func isTelemetryBits(s string) bool {
+	if len(s) != 8 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '0' && s[i] != '1' {
+			return false
+		}
+	}
+	return true
+}
+
 // parseTelemetry parses an APRS telemetry packet.
 // Format: T#seq,a1,a2,a3,a4,a5,bbbbbbbb
 func (p *Packet) parseTelemetry(opt *options) error {
@@ -106,3 +120,83 @@ func (p *Packet) parseTelemetry(opt *options) error {
 
 	return nil
 }
+
+// parseTelemetryDefinition parses the text of a PARM, UNIT, EQNS or BITS
+// telemetry definition message into p.TelemetryDef. Only the part of the
+// definition carried by the message is populated.
+func (p *Packet) parseTelemetryDefinition(text string) {
+	def := &TelemetryDefinition{}
+	p.TelemetryDef = def
+
+	keyword := strings.ToUpper(text[:4])
+	data := text[5:]
+
+	switch keyword {
+	case "PARM":
+		def.Names = splitTelemetryLabels(data)
+	case "UNIT":
+		def.Units = splitTelemetryLabels(data)
+	case "EQNS":
+		def.Eqns = p.parseTelemetryEqns(data)
+	case "BITS":
+		bits, project, _ := strings.Cut(data, ",")
+		bits = strings.TrimSpace(bits)
+		if isTelemetryBits(bits) {
+			def.BitSense = bits
+		} else {
+			p.warn(ErrTlmInvalid, fmt.Sprintf("invalid telemetry bit sense: %q", bits))
+		}
+		def.Project = strings.TrimSpace(project)
+	}
+}
+
+// splitTelemetryLabels splits the comma-separated channel names or units of
+// a PARM or UNIT message, up to 5 analog channels and 8 digital bits.
+func splitTelemetryLabels(data string) []string {
+	labels := strings.Split(data, ",")
+	if len(labels) > 13 {
+		labels = labels[:13]
+	}
+	for i := range labels {
+		labels[i] = strings.TrimSpace(labels[i])
+	}
+	return labels
+}
+
+// parseTelemetryEqns parses the a,b,c coefficients of up to 5 analog
+// channels from an EQNS message. Missing coefficients, and coefficients
+// which cannot be parsed, default to the identity equation (0, 1, 0).
+func (p *Packet) parseTelemetryEqns(data string) []TelemetryEqn {
+	fields := strings.Split(data, ",")
+	if len(fields) > 15 {
+		p.warn(ErrTlmInvalid, fmt.Sprintf("too many telemetry equation coefficients: %d", len(fields)))
+		fields = fields[:15]
+	}
+
+	eqns := make([]TelemetryEqn, (len(fields)+2)/3)
+	for i := range eqns {
+		eqns[i] = TelemetryEqn{A: 0, B: 1, C: 0}
+	}
+
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil || !isNumericValue(field) {
+			p.warn(ErrTlmInvalid, fmt.Sprintf("invalid telemetry equation coefficient %d: %q", i+1, field))
+			continue
+		}
+		switch i % 3 {
+		case 0:
+			eqns[i/3].A = v
+		case 1:
+			eqns[i/3].B = v
+		case 2:
+			eqns[i/3].C = v
+		}
+	}
+
+	return eqns
+}
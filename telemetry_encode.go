@@ -0,0 +1,203 @@
+package fap
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Maximum lengths of the PARM and UNIT fields of analog channels 1-5
+// followed by digital bits 1-8, from the APRS 1.0.1 specification.
+var telemetryFieldMaxLen = [13]int{7, 7, 6, 6, 5, 6, 5, 4, 4, 4, 3, 3, 3}
+
+// Maximum length of a telemetry project title in a BITS message.
+const telemetryProjectMaxLen = 23
+
+// Maximum length of APRS message text.
+const messageTextMaxLen = 67
+
+// EncodeTelemetry encodes a Telemetry struct into a T#sss,a1,a2,a3,a4,a5,bbbbbbbb
+// report body. Undefined (nil) values are sent as empty fields, and missing
+// digital bits as 00000000.
+func EncodeTelemetry(tlm *Telemetry) (string, error) {
+	if tlm == nil {
+		return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: "no telemetry"}
+	}
+	if tlm.Seq < 0 || tlm.Seq > 999 {
+		return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("telemetry sequence out of range: %d", tlm.Seq)}
+	}
+	if len(tlm.Vals) > 5 {
+		return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("too many telemetry values: %d", len(tlm.Vals))}
+	}
+
+	bits := tlm.Bits
+	if bits == "" {
+		bits = "00000000"
+	}
+	if !isTelemetryBits(bits) {
+		return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("invalid telemetry bits: %q", tlm.Bits)}
+	}
+
+	fields := make([]string, 5)
+	for i, v := range tlm.Vals {
+		if v == nil {
+			continue
+		}
+		if math.IsNaN(*v) || math.IsInf(*v, 0) {
+			return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("telemetry value %d is not a number", i+1)}
+		}
+		fields[i] = strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+
+	return fmt.Sprintf("T#%03d,%s,%s", tlm.Seq, strings.Join(fields, ","), bits), nil
+}
+
+// EncodeTelemetryDefinition encodes a TelemetryDefinition into the PARM,
+// UNIT, EQNS and BITS message bodies addressed to station, which is the
+// callsign of the station sending the telemetry. Messages are only created
+// for the parts of the definition which are set. Channel names and units
+// are limited to the field lengths given in the APRS specification.
+func EncodeTelemetryDefinition(station string, def *TelemetryDefinition) ([]string, error) {
+	if def == nil {
+		return nil, &ParseError{Code: ErrTlmEncInvalid.Code, Msg: "no telemetry definition"}
+	}
+
+	var texts []string
+
+	if len(def.Names) > 0 {
+		s, err := encodeTelemetryLabels("PARM", def.Names)
+		if err != nil {
+			return nil, err
+		}
+		texts = append(texts, s)
+	}
+
+	if len(def.Units) > 0 {
+		s, err := encodeTelemetryLabels("UNIT", def.Units)
+		if err != nil {
+			return nil, err
+		}
+		texts = append(texts, s)
+	}
+
+	if len(def.Eqns) > 0 {
+		if len(def.Eqns) > 5 {
+			return nil, &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("too many telemetry equations: %d", len(def.Eqns))}
+		}
+		coeffs := make([]string, 0, 3*len(def.Eqns))
+		for _, eq := range def.Eqns {
+			for _, c := range []float64{eq.A, eq.B, eq.C} {
+				if math.IsNaN(c) || math.IsInf(c, 0) {
+					return nil, &ParseError{Code: ErrTlmEncInvalid.Code, Msg: "telemetry equation coefficient is not a number"}
+				}
+				coeffs = append(coeffs, strconv.FormatFloat(c, 'f', -1, 64))
+			}
+		}
+		texts = append(texts, "EQNS."+strings.Join(coeffs, ","))
+	}
+
+	if def.BitSense != "" || def.Project != "" {
+		bits := def.BitSense
+		if bits == "" {
+			bits = "11111111"
+		}
+		if !isTelemetryBits(bits) {
+			return nil, &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("invalid telemetry bit sense: %q", def.BitSense)}
+		}
+		if len(def.Project) > telemetryProjectMaxLen {
+			return nil, &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("telemetry project title too long (max %d characters)", telemetryProjectMaxLen)}
+		}
+		texts = append(texts, "BITS."+bits+","+def.Project)
+	}
+
+	bodies := make([]string, 0, len(texts))
+	for _, text := range texts {
+		if len(text) > messageTextMaxLen {
+			return nil, &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("telemetry %s message too long (max %d characters)", text[:4], messageTextMaxLen)}
+		}
+		body, err := EncodeMessage(&Message{Destination: station, Text: text})
+		if err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, body)
+	}
+
+	return bodies, nil
+}
+
+// encodeTelemetryLabels creates the text of a PARM or UNIT message.
+func encodeTelemetryLabels(keyword string, labels []string) (string, error) {
+	if len(labels) > len(telemetryFieldMaxLen) {
+		return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("too many %s fields: %d", keyword, len(labels))}
+	}
+	for i, l := range labels {
+		if len(l) > telemetryFieldMaxLen[i] {
+			return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("%s field %d too long (max %d characters): %q", keyword, i+1, telemetryFieldMaxLen[i], l)}
+		}
+		if strings.ContainsAny(l, ",{") || !isPrintableASCII(l) {
+			return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("%s field %d contains invalid characters: %q", keyword, i+1, l)}
+		}
+	}
+	return keyword + "." + strings.Join(labels, ","), nil
+}
+
+// EncodeBase91Telemetry encodes telemetry in the base-91 |ss11223344| form
+// appended to position comments. The sequence number and up to 5 analog
+// values must be in the range 0-8280; digital bits can only be sent
+// together with all 5 values.
+func EncodeBase91Telemetry(tlm *Telemetry) (string, error) {
+	const maxVal = 91*91 - 1
+
+	if tlm == nil {
+		return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: "no telemetry"}
+	}
+	if tlm.Seq < 0 || tlm.Seq > maxVal {
+		return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("base-91 telemetry sequence out of range: %d", tlm.Seq)}
+	}
+
+	// Number of channels is given by the last defined value
+	n := 0
+	for i, v := range tlm.Vals {
+		if v != nil {
+			n = i + 1
+		}
+	}
+	if n > 5 {
+		return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("too many telemetry values: %d", n)}
+	}
+	if tlm.Bits != "" {
+		n = 5
+	}
+	if n == 0 {
+		return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: "base-91 telemetry needs at least one value"}
+	}
+
+	result := "|" + encodeBase91(tlm.Seq, 2)
+	for i := range n {
+		if i >= len(tlm.Vals) || tlm.Vals[i] == nil {
+			return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("telemetry value %d is undefined", i+1)}
+		}
+		v := int(math.Round(*tlm.Vals[i]))
+		if v < 0 || v > maxVal {
+			return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("base-91 telemetry value %d out of range: %f", i+1, *tlm.Vals[i])}
+		}
+		result += encodeBase91(v, 2)
+	}
+
+	if tlm.Bits != "" {
+		if !isTelemetryBits(tlm.Bits) {
+			return "", &ParseError{Code: ErrTlmEncInvalid.Code, Msg: fmt.Sprintf("invalid telemetry bits: %q", tlm.Bits)}
+		}
+		// Bits are sent LSB first
+		bits := 0
+		for b := range 8 {
+			if tlm.Bits[b] == '1' {
+				bits |= 1 << b
+			}
+		}
+		result += encodeBase91(bits, 2)
+	}
+
+	return result + "|", nil
+}
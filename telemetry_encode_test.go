@@ -0,0 +1,203 @@
+package fap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncodeTelemetry(t *testing.T) {
+	tests := []struct {
+		name string
+		tlm  *Telemetry
+		want string
+	}{
+		{
+			"classic",
+			&Telemetry{Seq: 324, Vals: []*float64{new(0.0), new(38.0), new(255.0), new(0.12), new(50.12)}, Bits: "01000001"},
+			"T#324,0,38,255,0.12,50.12,01000001",
+		},
+		{
+			"relaxed values",
+			&Telemetry{Seq: 1, Vals: []*float64{new(-1.0), new(2147483647.0), new(-2147483648.0), new(0.000001), new(-0.0000001)}, Bits: "01000001"},
+			"T#001,-1,2147483647,-2147483648,0.000001,-0.0000001,01000001",
+		},
+		{
+			"undefined values and no bits",
+			&Telemetry{Seq: 5, Vals: []*float64{new(1.0), nil, new(3.0)}},
+			"T#005,1,,3,,,00000000",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := EncodeTelemetry(tc.tlm)
+			if err != nil {
+				t.Fatalf("EncodeTelemetry failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+
+			p, err := Parse("SRCCALL>APRS:" + got)
+			if err != nil {
+				t.Fatalf("failed to parse encoded telemetry: %v", err)
+			}
+			if p.TelemetryData.Seq != tc.tlm.Seq {
+				t.Errorf("seq = %d, want %d", p.TelemetryData.Seq, tc.tlm.Seq)
+			}
+			for i, v := range tc.tlm.Vals {
+				got := p.TelemetryData.Vals[i]
+				if (v == nil) != (got == nil) || v != nil && *v != *got {
+					t.Errorf("vals[%d] = %v, want %v", i, got, v)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeTelemetryErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		tlm  *Telemetry
+	}{
+		{"nil", nil},
+		{"negative sequence", &Telemetry{Seq: -1}},
+		{"sequence too large", &Telemetry{Seq: 1000}},
+		{"too many values", &Telemetry{Vals: make([]*float64, 6)}},
+		{"bits too short", &Telemetry{Bits: "0101"}},
+		{"bits not binary", &Telemetry{Bits: "0101010a"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := EncodeTelemetry(tc.tlm)
+			if !errors.Is(err, ErrTlmEncInvalid) {
+				t.Errorf("expected ErrTlmEncInvalid, got %v", err)
+			}
+		})
+	}
+
+	if _, err := EncodeBase91Telemetry(nil); !errors.Is(err, ErrTlmEncInvalid) {
+		t.Errorf("EncodeBase91Telemetry(nil): expected ErrTlmEncInvalid, got %v", err)
+	}
+}
+
+func TestEncodeTelemetryDefinition(t *testing.T) {
+	def := &TelemetryDefinition{
+		Names:    []string{"Battery", "Btemp", "ATemp", "Pres", "Alt", "Camra", "Chute", "Sun", "10m", "ATV"},
+		Units:    []string{"v/100", "deg.F", "deg.F", "Mbar", "Kft", "Click", "OPEN", "on", "on", "hi"},
+		Eqns:     []TelemetryEqn{{0, 5.2, 0}, {0, 0.53, -32}, {3, 4.39, 49}, {-32, 3, 4.39}, {49, -32, 3}},
+		BitSense: "10110000",
+		Project:  "N0QBF's Big Balloon",
+	}
+	want := []string{
+		":N0QBF-11 :PARM.Battery,Btemp,ATemp,Pres,Alt,Camra,Chute,Sun,10m,ATV",
+		":N0QBF-11 :UNIT.v/100,deg.F,deg.F,Mbar,Kft,Click,OPEN,on,on,hi",
+		":N0QBF-11 :EQNS.0,5.2,0,0,0.53,-32,3,4.39,49,-32,3,4.39,49,-32,3",
+		":N0QBF-11 :BITS.10110000,N0QBF's Big Balloon",
+	}
+
+	got, err := EncodeTelemetryDefinition("N0QBF-11", def)
+	if err != nil {
+		t.Fatalf("EncodeTelemetryDefinition failed: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d messages, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("message %d = %q, want %q", i, got[i], want[i])
+		}
+		p, err := Parse("N0QBF-11>APRS:" + got[i])
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", got[i], err)
+		}
+		if p.Type != PacketTypeTelemetryMessage {
+			t.Errorf("type = %q, want %q", p.Type, PacketTypeTelemetryMessage)
+		}
+		if p.TelemetryDef == nil {
+			t.Fatalf("no telemetry definition in %q", got[i])
+		}
+	}
+}
+
+func TestEncodeTelemetryDefinitionPartial(t *testing.T) {
+	got, err := EncodeTelemetryDefinition("N0CALL", &TelemetryDefinition{Project: "Solar site"})
+	if err != nil {
+		t.Fatalf("EncodeTelemetryDefinition failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != ":N0CALL   :BITS.11111111,Solar site" {
+		t.Errorf("got %q, want only a BITS message", got)
+	}
+}
+
+func TestEncodeTelemetryDefinitionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		station string
+		def     *TelemetryDefinition
+		wantErr error
+	}{
+		{"nil", "N0CALL", nil, ErrTlmEncInvalid},
+		{"analog name too long", "N0CALL", &TelemetryDefinition{Names: []string{"Battery1"}}, ErrTlmEncInvalid},
+		{"bit name too long", "N0CALL", &TelemetryDefinition{Names: []string{"", "", "", "", "", "", "", "", "", "", "", "", "ABCD"}}, ErrTlmEncInvalid},
+		{"too many names", "N0CALL", &TelemetryDefinition{Names: make([]string, 14)}, ErrTlmEncInvalid},
+		{"unit with comma", "N0CALL", &TelemetryDefinition{Units: []string{"a,b"}}, ErrTlmEncInvalid},
+		{"too many equations", "N0CALL", &TelemetryDefinition{Eqns: make([]TelemetryEqn, 6)}, ErrTlmEncInvalid},
+		{"equations too long", "N0CALL", &TelemetryDefinition{Eqns: []TelemetryEqn{
+			{1.2345, 1.2345, 1.2345}, {1.2345, 1.2345, 1.2345}, {1.2345, 1.2345, 1.2345}, {1.2345, 1.2345, 1.2345}, {1.2345, 1.2345, 1.2345},
+		}}, ErrTlmEncInvalid},
+		{"invalid bit sense", "N0CALL", &TelemetryDefinition{BitSense: "1111"}, ErrTlmEncInvalid},
+		{"project too long", "N0CALL", &TelemetryDefinition{Project: strings.Repeat("x", 24)}, ErrTlmEncInvalid},
+		{"station too long", "N0CALL-123", &TelemetryDefinition{Project: "x"}, ErrMsgDstTooLong},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := EncodeTelemetryDefinition(tc.station, tc.def)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEncodeBase91Telemetry(t *testing.T) {
+	tests := []struct {
+		tlm  *Telemetry
+		want string
+	}{
+		{&Telemetry{Seq: 0, Vals: []*float64{new(0.0), new(0.0), new(0.0), new(0.0), new(0.0)}, Bits: "00000000"}, "|!!!!!!!!!!!!!!|"},
+		{&Telemetry{Seq: 0, Vals: []*float64{new(0.0)}}, "|!!!!|"},
+		{&Telemetry{Seq: 8280, Vals: []*float64{new(1.0), new(8280.0)}}, "|{{!\"{{|"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.want, func(t *testing.T) {
+			got, err := EncodeBase91Telemetry(tc.tlm)
+			if err != nil {
+				t.Fatalf("EncodeBase91Telemetry failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+
+			p, err := Parse("OH7LZB-13>SX15S6:'I',l \x1c>/ comment " + got)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if p.TelemetryData == nil {
+				t.Fatal("no telemetry data")
+			}
+			if p.TelemetryData.Seq != tc.tlm.Seq || p.TelemetryData.Bits != tc.tlm.Bits {
+				t.Errorf("seq/bits = %d/%q, want %d/%q", p.TelemetryData.Seq, p.TelemetryData.Bits, tc.tlm.Seq, tc.tlm.Bits)
+			}
+			for i, v := range tc.tlm.Vals {
+				if got := p.TelemetryData.Vals[i]; got == nil || *got != *v {
+					t.Errorf("vals[%d] = %v, want %v", i, got, *v)
+				}
+			}
+		})
+	}
+}
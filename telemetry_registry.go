@@ -0,0 +1,242 @@
+package fap
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ScaledTelemetry contains telemetry values converted to engineering units
+// using a TelemetryDefinition.
+type ScaledTelemetry struct {
+	Seq     int              // Sequence number
+	Project string           // Project title from the BITS message
+	Analog  []TelemetryValue // Analog channels 1-5
+	Digital []TelemetryBit   // Digital bits 1-8 (empty if the report has no bits)
+}
+
+// TelemetryValue is a scaled analog telemetry channel.
+type TelemetryValue struct {
+	Name  string   // Channel name from PARM
+	Unit  string   // Unit from UNIT
+	Raw   *float64 // Raw value from the report (nil = undefined)
+	Value *float64 // Scaled value, A*x^2 + B*x + C (nil = undefined)
+}
+
+// TelemetryBit is an interpreted digital telemetry bit.
+type TelemetryBit struct {
+	Name string // Bit name from PARM
+	Unit string // Bit label from UNIT
+	Raw  bool   // Raw bit value from the report
+	On   bool   // Whether the bit is active according to the BITS bit sense
+}
+
+// Scale converts raw telemetry into named values in engineering units.
+// Channels without an equation are passed through unscaled, and bits are
+// treated as active high if no bit sense has been defined.
+func (d *TelemetryDefinition) Scale(tlm *Telemetry) *ScaledTelemetry {
+	st := &ScaledTelemetry{
+		Seq:     tlm.Seq,
+		Project: d.Project,
+	}
+
+	for i, raw := range tlm.Vals {
+		if i >= 5 {
+			break
+		}
+		tv := TelemetryValue{
+			Name: labelAt(d.Names, i),
+			Unit: labelAt(d.Units, i),
+			Raw:  raw,
+		}
+		if raw != nil {
+			eq := TelemetryEqn{A: 0, B: 1, C: 0}
+			if i < len(d.Eqns) {
+				eq = d.Eqns[i]
+			}
+			v := eq.A**raw**raw + eq.B**raw + eq.C
+			tv.Value = &v
+		}
+		st.Analog = append(st.Analog, tv)
+	}
+
+	if isTelemetryBits(tlm.Bits) {
+		sense := d.BitSense
+		if !isTelemetryBits(sense) {
+			sense = "11111111"
+		}
+		for i := range 8 {
+			st.Digital = append(st.Digital, TelemetryBit{
+				Name: labelAt(d.Names, 5+i),
+				Unit: labelAt(d.Units, 5+i),
+				Raw:  tlm.Bits[i] == '1',
+				On:   tlm.Bits[i] == sense[i],
+			})
+		}
+	}
+
+	return st
+}
+
+// labelAt returns labels[i], or an empty string if it is not defined.
+func labelAt(labels []string, i int) string {
+	if i < len(labels) {
+		return labels[i]
+	}
+	return ""
+}
+
+// TelemetryRegistry collects telemetry definitions of stations from PARM,
+// UNIT, EQNS and BITS messages, and scales their telemetry reports using
+// them. It is safe for concurrent use, and the zero value is an empty registry.
+type TelemetryRegistry struct {
+	mu   sync.RWMutex
+	defs map[string]*TelemetryDefinition
+}
+
+// NewTelemetryRegistry creates an empty TelemetryRegistry.
+func NewTelemetryRegistry() *TelemetryRegistry {
+	return &TelemetryRegistry{
+		defs: make(map[string]*TelemetryDefinition),
+	}
+}
+
+// Ingest stores the telemetry definition carried by a telemetry definition
+// message. Definitions apply to the station the message is addressed to,
+// which is normally the sending station itself. Each message updates only
+// its own part of the station's definition. Returns true if the packet was
+// a telemetry definition message.
+func (r *TelemetryRegistry) Ingest(p *Packet) bool {
+	if p.Type != PacketTypeTelemetryMessage || p.TelemetryDef == nil || p.Message == nil {
+		return false
+	}
+
+	key := strings.ToUpper(p.Message.Destination)
+	in := p.TelemetryDef
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.defs == nil {
+		r.defs = make(map[string]*TelemetryDefinition)
+	}
+	def := r.defs[key]
+	if def == nil {
+		def = &TelemetryDefinition{}
+		r.defs[key] = def
+	}
+
+	if in.Names != nil {
+		def.Names = slices.Clone(in.Names)
+	}
+	if in.Units != nil {
+		def.Units = slices.Clone(in.Units)
+	}
+	if in.Eqns != nil {
+		def.Eqns = slices.Clone(in.Eqns)
+	}
+	if in.BitSense != "" || in.Project != "" {
+		def.BitSense = in.BitSense
+		def.Project = in.Project
+	}
+
+	return true
+}
+
+// Definition returns a copy of the telemetry definition of a station,
+// or nil if none has been received.
+func (r *TelemetryRegistry) Definition(station string) *TelemetryDefinition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def := r.defs[strings.ToUpper(station)]
+	if def == nil {
+		return nil
+	}
+	return def.clone()
+}
+
+// SetDefinition replaces the telemetry definition of a station. A nil
+// definition removes it, like Remove.
+func (r *TelemetryRegistry) SetDefinition(station string, def *TelemetryDefinition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if def == nil {
+		delete(r.defs, strings.ToUpper(station))
+		return
+	}
+
+	if r.defs == nil {
+		r.defs = make(map[string]*TelemetryDefinition)
+	}
+	r.defs[strings.ToUpper(station)] = def.clone()
+}
+
+// Remove forgets the telemetry definition of a station.
+func (r *TelemetryRegistry) Remove(station string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.defs, strings.ToUpper(station))
+}
+
+// Scale converts the telemetry of a T# report or a position with base-91
+// telemetry using the definition of the source station. Telemetry of
+// stations without a definition is returned unscaled. Returns nil if the
+// packet has no telemetry.
+func (r *TelemetryRegistry) Scale(p *Packet) *ScaledTelemetry {
+	if p.TelemetryData == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def := r.defs[strings.ToUpper(p.SrcCallsign)]
+	if def == nil {
+		def = &TelemetryDefinition{}
+	}
+	return def.Scale(p.TelemetryData)
+}
+
+// MarshalJSON returns a snapshot of the registry as a JSON object keyed by
+// station callsign.
+func (r *TelemetryRegistry) MarshalJSON() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return json.Marshal(r.defs)
+}
+
+// UnmarshalJSON replaces the contents of the registry with a snapshot
+// created by MarshalJSON.
+func (r *TelemetryRegistry) UnmarshalJSON(data []byte) error {
+	defs := make(map[string]*TelemetryDefinition)
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.defs = make(map[string]*TelemetryDefinition, len(defs))
+	for station, def := range defs {
+		if def != nil {
+			r.defs[strings.ToUpper(station)] = def
+		}
+	}
+	return nil
+}
+
+// clone returns a deep copy of the definition.
+func (d *TelemetryDefinition) clone() *TelemetryDefinition {
+	return &TelemetryDefinition{
+		Names:    slices.Clone(d.Names),
+		Units:    slices.Clone(d.Units),
+		Eqns:     slices.Clone(d.Eqns),
+		BitSense: d.BitSense,
+		Project:  d.Project,
+	}
+}
@@ -0,0 +1,229 @@
+package fap
+
+import (
+	"encoding/json"
+	"math"
+	"sync"
+	"testing"
+)
+
+// ingestAll parses and ingests the given packets into the registry.
+func ingestAll(t *testing.T, r *TelemetryRegistry, packets ...string) {
+	t.Helper()
+	for _, raw := range packets {
+		p, err := Parse(raw)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", raw, err)
+		}
+		if !r.Ingest(p) {
+			t.Fatalf("packet %q was not ingested", raw)
+		}
+	}
+}
+
+func TestTelemetryRegistryScale(t *testing.T) {
+	r := NewTelemetryRegistry()
+	ingestAll(t, r,
+		"N0QBF-11>APRS::N0QBF-11 :PARM.Battery,Btemp,ATemp,Pres,Alt,Camra,Chute,Sun,10m,ATV",
+		"N0QBF-11>APRS::N0QBF-11 :UNIT.v/100,deg.F,deg.F,Mbar,Kft,Click,OPEN,on,on,hi",
+		"N0QBF-11>APRS::N0QBF-11 :EQNS.0,5.2,0,0,.53,-32,3,4.39,49,-32,3,4.39",
+		"N0QBF-11>APRS::N0QBF-11 :BITS.10110000,N0QBF's Big Balloon",
+	)
+
+	p, err := Parse("N0QBF-11>APRS:T#005,199,000,255,073,123,01101001")
+	if err != nil {
+		t.Fatalf("failed to parse telemetry: %v", err)
+	}
+	st := r.Scale(p)
+	if st == nil {
+		t.Fatal("no scaled telemetry")
+	}
+	if st.Seq != 5 || st.Project != "N0QBF's Big Balloon" {
+		t.Errorf("seq/project = %d/%q", st.Seq, st.Project)
+	}
+
+	wantAnalog := []struct {
+		name, unit string
+		value      float64
+	}{
+		{"Battery", "v/100", 1034.8},
+		{"Btemp", "deg.F", -32},
+		{"ATemp", "deg.F", 3*255*255 + 4.39*255 + 49},
+		{"Pres", "Mbar", -32*73*73 + 3*73 + 4.39},
+		{"Alt", "Kft", 123},
+	}
+	if len(st.Analog) != len(wantAnalog) {
+		t.Fatalf("analog channels = %d, want %d", len(st.Analog), len(wantAnalog))
+	}
+	for i, want := range wantAnalog {
+		got := st.Analog[i]
+		if got.Name != want.name || got.Unit != want.unit {
+			t.Errorf("analog[%d] = %q %q, want %q %q", i, got.Name, got.Unit, want.name, want.unit)
+		}
+		if got.Value == nil || math.Abs(*got.Value-want.value) > 1e-9 {
+			t.Errorf("analog[%d] value = %v, want %f", i, got.Value, want.value)
+		}
+	}
+
+	// Bits 01101001 with sense 10110000
+	wantOn := []bool{false, false, true, false, false, true, true, false}
+	if len(st.Digital) != 8 {
+		t.Fatalf("digital bits = %d, want 8", len(st.Digital))
+	}
+	for i, want := range wantOn {
+		if st.Digital[i].On != want {
+			t.Errorf("digital[%d].On = %v, want %v", i, st.Digital[i].On, want)
+		}
+	}
+	if st.Digital[0].Name != "Camra" || st.Digital[0].Unit != "Click" || st.Digital[0].Raw {
+		t.Errorf("digital[0] = %+v", st.Digital[0])
+	}
+	if st.Digital[7].Name != "" {
+		t.Errorf("digital[7].Name = %q, want empty", st.Digital[7].Name)
+	}
+}
+
+func TestTelemetryRegistryMicE(t *testing.T) {
+	var r TelemetryRegistry
+	ingestAll(t, &r,
+		"OH7LZB-13>APRS::OH7LZB-13:PARM.Vbat",
+		"OH7LZB-13>APRS::OH7LZB-13:EQNS.0,0.01,0",
+	)
+
+	p, err := Parse("OH7LZB-13>SX15S6:'I',l \x1c>/ comment |!!\"!|")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	st := r.Scale(p)
+	if st == nil || len(st.Analog) == 0 {
+		t.Fatal("no scaled telemetry")
+	}
+	if st.Analog[0].Name != "Vbat" || st.Analog[0].Value == nil || *st.Analog[0].Value != 0.91 {
+		t.Errorf("analog[0] = %+v, want Vbat 0.91", st.Analog[0])
+	}
+	if st.Analog[1].Value != nil {
+		t.Errorf("analog[1] value = %v, want nil", *st.Analog[1].Value)
+	}
+	if len(st.Digital) != 0 {
+		t.Errorf("digital bits = %d, want 0", len(st.Digital))
+	}
+}
+
+func TestTelemetryRegistryUnknownStation(t *testing.T) {
+	r := NewTelemetryRegistry()
+	p, err := Parse("N0CALL>APRS:T#001,10,20,,,,11110000")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	st := r.Scale(p)
+	if st.Analog[0].Value == nil || *st.Analog[0].Value != 10 {
+		t.Errorf("analog[0] value = %v, want 10", st.Analog[0].Value)
+	}
+	if !st.Digital[0].On || st.Digital[7].On {
+		t.Errorf("bits should be active high without a definition: %+v", st.Digital)
+	}
+
+	p, _ = Parse("N0CALL>APRS::N0CALL   :Hello")
+	if r.Ingest(p) {
+		t.Error("regular message was ingested")
+	}
+	if r.Definition("N0CALL") != nil {
+		t.Error("definition exists for N0CALL")
+	}
+}
+
+func TestTelemetryRegistryPartialUpdate(t *testing.T) {
+	r := NewTelemetryRegistry()
+	ingestAll(t, r,
+		"N0CALL>APRS::N0CALL   :PARM.A,B",
+		"N0CALL>APRS::N0CALL   :UNIT.V,A",
+		"N0CALL>APRS::N0CALL   :PARM.C,D",
+	)
+	def := r.Definition("n0call")
+	if def == nil {
+		t.Fatal("no definition")
+	}
+	if len(def.Names) != 2 || def.Names[0] != "C" || len(def.Units) != 2 || def.Units[1] != "A" {
+		t.Errorf("definition = %+v", def)
+	}
+
+	// Returned definitions are copies
+	def.Names[0] = "X"
+	if r.Definition("N0CALL").Names[0] != "C" {
+		t.Error("modifying a returned definition changed the registry")
+	}
+
+	r.Remove("N0CALL")
+	if r.Definition("N0CALL") != nil {
+		t.Error("definition not removed")
+	}
+}
+
+func TestTelemetryRegistrySetNil(t *testing.T) {
+	var r TelemetryRegistry
+
+	// A nil definition on an empty registry is ignored
+	r.SetDefinition("N0CALL", nil)
+	if def := r.Definition("N0CALL"); def != nil {
+		t.Errorf("definition = %+v, want nil", def)
+	}
+
+	r.SetDefinition("N0CALL", &TelemetryDefinition{Names: []string{"Volts"}})
+	r.SetDefinition("n0call", nil)
+	if def := r.Definition("N0CALL"); def != nil {
+		t.Errorf("definition after nil = %+v, want nil", def)
+	}
+}
+
+func TestTelemetryRegistrySnapshot(t *testing.T) {
+	r := NewTelemetryRegistry()
+	r.SetDefinition("N0CALL-1", &TelemetryDefinition{
+		Names:    []string{"Vbat"},
+		Eqns:     []TelemetryEqn{{0, 0.1, 0}},
+		BitSense: "00000000",
+		Project:  "Test",
+	})
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	var restored TelemetryRegistry
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	def := restored.Definition("N0CALL-1")
+	if def == nil {
+		t.Fatal("definition not restored")
+	}
+	if def.Names[0] != "Vbat" || def.Eqns[0].B != 0.1 || def.BitSense != "00000000" || def.Project != "Test" {
+		t.Errorf("restored definition = %+v", def)
+	}
+
+	if err := restored.UnmarshalJSON([]byte("[")); err == nil {
+		t.Error("expected error for invalid snapshot")
+	}
+}
+
+func TestTelemetryRegistryConcurrent(t *testing.T) {
+	r := NewTelemetryRegistry()
+	def, _ := Parse("N0CALL>APRS::N0CALL   :EQNS.0,2,0")
+	tlm, _ := Parse("N0CALL>APRS:T#001,10")
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				r.Ingest(def)
+				r.Scale(tlm)
+				_, _ = json.Marshal(r)
+			}
+		})
+	}
+	wg.Wait()
+
+	if v := r.Scale(tlm).Analog[0].Value; v == nil || *v != 20 {
+		t.Errorf("scaled value = %v, want 20", v)
+	}
+}
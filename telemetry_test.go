@@ -2,6 +2,7 @@ package fap
 
 import (
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -313,3 +314,120 @@ func TestRegularMessageNotTelemetryMessage(t *testing.T) {
 		t.Errorf("type = %q, want %q", p.Type, PacketTypeMessage)
 	}
 }
+
+func TestTelemetryDefinitionMessages(t *testing.T) {
+	p, err := Parse("SRCCALL>APRS::N0CALL-10:PARM.Vin,Rx1h,Dg1h,Eff1h,EffTo,O1,O2,O3,O4,I1,I2,I3,I4")
+	if err != nil {
+		t.Fatalf("failed to parse PARM message: %v", err)
+	}
+	if p.TelemetryDef == nil {
+		t.Fatal("no telemetry definition")
+	}
+	wantNames := []string{"Vin", "Rx1h", "Dg1h", "Eff1h", "EffTo", "O1", "O2", "O3", "O4", "I1", "I2", "I3", "I4"}
+	if strings.Join(p.TelemetryDef.Names, ",") != strings.Join(wantNames, ",") {
+		t.Errorf("names = %q, want %q", p.TelemetryDef.Names, wantNames)
+	}
+	if p.TelemetryDef.Units != nil || p.TelemetryDef.Eqns != nil {
+		t.Errorf("units/eqns set in PARM message")
+	}
+
+	p, err = Parse("SRCCALL>APRS::N0CALL-10:unit.Volt, Pkt/h{12")
+	if err != nil {
+		t.Fatalf("failed to parse UNIT message: %v", err)
+	}
+	if strings.Join(p.TelemetryDef.Units, ",") != "Volt,Pkt/h" {
+		t.Errorf("units = %q, want [Volt Pkt/h]", p.TelemetryDef.Units)
+	}
+
+	p, err = Parse("SRCCALL>APRS::N0CALL-10:BITS.10110000,N0QBF's Big Balloon")
+	if err != nil {
+		t.Fatalf("failed to parse BITS message: %v", err)
+	}
+	if p.TelemetryDef.BitSense != "10110000" {
+		t.Errorf("bit sense = %q, want %q", p.TelemetryDef.BitSense, "10110000")
+	}
+	if p.TelemetryDef.Project != "N0QBF's Big Balloon" {
+		t.Errorf("project = %q, want %q", p.TelemetryDef.Project, "N0QBF's Big Balloon")
+	}
+	if len(p.Warnings) != 0 {
+		t.Errorf("warnings = %v, want none", p.Warnings)
+	}
+}
+
+func TestTelemetryDefinitionEqns(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		want         []TelemetryEqn
+		wantWarnings int
+	}{
+		{
+			"full",
+			"EQNS.0,5.2,0,0,.53,-32,3,4.39,49,-32,3,4.39,49,-32,3",
+			[]TelemetryEqn{{0, 5.2, 0}, {0, 0.53, -32}, {3, 4.39, 49}, {-32, 3, 4.39}, {49, -32, 3}},
+			0,
+		},
+		{
+			"partial channel",
+			"EQNS.0,0.075,0,0,2",
+			[]TelemetryEqn{{0, 0.075, 0}, {0, 2, 0}},
+			0,
+		},
+		{
+			"malformed coefficient",
+			"EQNS.0,abc,1,0,1,0",
+			[]TelemetryEqn{{0, 1, 1}, {0, 1, 0}},
+			1,
+		},
+		{
+			"too many coefficients",
+			"EQNS.0,1,0,0,1,0,0,1,0,0,1,0,0,1,0,0",
+			[]TelemetryEqn{{0, 1, 0}, {0, 1, 0}, {0, 1, 0}, {0, 1, 0}, {0, 1, 0}},
+			1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Parse("SRCCALL>APRS::N0CALL   :" + tc.text)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if p.TelemetryDef == nil {
+				t.Fatal("no telemetry definition")
+			}
+			if len(p.TelemetryDef.Eqns) != len(tc.want) {
+				t.Fatalf("eqns = %v, want %v", p.TelemetryDef.Eqns, tc.want)
+			}
+			for i := range tc.want {
+				if p.TelemetryDef.Eqns[i] != tc.want[i] {
+					t.Errorf("eqns[%d] = %v, want %v", i, p.TelemetryDef.Eqns[i], tc.want[i])
+				}
+			}
+			if len(p.Warnings) != tc.wantWarnings {
+				t.Errorf("warnings = %v, want %d", p.Warnings, tc.wantWarnings)
+			}
+			for i := range p.Warnings {
+				if !errors.Is(&p.Warnings[i], ErrTlmInvalid) {
+					t.Errorf("warning = %v, want %v", p.Warnings[i], ErrTlmInvalid)
+				}
+			}
+		})
+	}
+}
+
+func TestTelemetryDefinitionInvalidBits(t *testing.T) {
+	p, err := Parse("SRCCALL>APRS::N0CALL   :BITS.1111,Project")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if p.TelemetryDef.BitSense != "" {
+		t.Errorf("bit sense = %q, want empty", p.TelemetryDef.BitSense)
+	}
+	if p.TelemetryDef.Project != "Project" {
+		t.Errorf("project = %q, want %q", p.TelemetryDef.Project, "Project")
+	}
+	if len(p.Warnings) != 1 || !errors.Is(&p.Warnings[0], ErrTlmInvalid) {
+		t.Errorf("warnings = %v, want one %v", p.Warnings, ErrTlmInvalid)
+	}
+}
@@ -0,0 +1,28 @@
+package fap
+
+import "fmt"
+
+// maxThirdPartyDepth is the maximum nesting level of third-party packets.
+const maxThirdPartyDepth = 3
+
+// parseThirdParty parses a third-party packet, which encapsulates another
+// packet with its own header: }SRC>DST,PATH:body
+// The encapsulated packet is parsed into p.ThirdParty, and its parse error,
+// if any, is returned as the error of the outer packet.
+func (p *Packet) parseThirdParty(opt *options) error {
+	p.Type = PacketTypeThirdParty
+
+	if opt.depth >= maxThirdPartyDepth {
+		return p.fail(ErrThirdPartyDepth, fmt.Sprintf("third-party packets nested more than %d levels deep", maxThirdPartyDepth))
+	}
+
+	inner := *opt
+	inner.depth++
+	// The encapsulated header was not transmitted as an AX.25 address
+	// field, so it is validated like an APRS-IS header.
+	inner.isAX25 = false
+
+	tp, err := parse(p.Body[1:], &inner)
+	p.ThirdParty = tp
+	return err
+}
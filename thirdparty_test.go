@@ -0,0 +1,121 @@
+package fap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestThirdPartyMessage(t *testing.T) {
+	packet := "OH2XYZ-10>APRX29,WIDE2-1,qAR,OH2IGT:}N0CALL-9>APRS,TCPIP,OH2XYZ-10*::OH2ABC   :Hello there{12"
+
+	p, err := Parse(packet)
+	if err != nil {
+		t.Fatalf("failed to parse third-party packet: %v", err)
+	}
+
+	if p.Type != PacketTypeThirdParty {
+		t.Errorf("type = %q, want %q", p.Type, PacketTypeThirdParty)
+	}
+	if p.SrcCallsign != "OH2XYZ-10" || p.DstCallsign != "APRX29" {
+		t.Errorf("outer header = %s>%s, want OH2XYZ-10>APRX29", p.SrcCallsign, p.DstCallsign)
+	}
+
+	tp := p.ThirdParty
+	if tp == nil {
+		t.Fatal("third-party packet is nil")
+	}
+	if tp.OrigPacket != "N0CALL-9>APRS,TCPIP,OH2XYZ-10*::OH2ABC   :Hello there{12" {
+		t.Errorf("inner packet = %q", tp.OrigPacket)
+	}
+	if tp.SrcCallsign != "N0CALL-9" || tp.DstCallsign != "APRS" {
+		t.Errorf("inner header = %s>%s, want N0CALL-9>APRS", tp.SrcCallsign, tp.DstCallsign)
+	}
+	if len(tp.Digipeaters) != 2 || tp.Digipeaters[0].Call != "TCPIP" || !tp.Digipeaters[1].WasDigied {
+		t.Errorf("inner digipeaters = %+v", tp.Digipeaters)
+	}
+	if tp.Type != PacketTypeMessage || tp.Message == nil {
+		t.Fatalf("inner type = %q, want %q", tp.Type, PacketTypeMessage)
+	}
+	if tp.Message.Destination != "OH2ABC" || tp.Message.Text != "Hello there" || tp.Message.ID != "12" {
+		t.Errorf("inner message = %+v", tp.Message)
+	}
+}
+
+func TestThirdPartyPosition(t *testing.T) {
+	packet := "OH2XYZ>APRS,qAR,OH2IGT:}OH2RDP-1>BEACON-15,TCPIP,OH2XYZ*:=6030.35N/02443.91E_150/002g004t039r001P002p004h00b10125XRSW"
+
+	p, err := Parse(packet, WithAX25())
+	if err != nil {
+		t.Fatalf("failed to parse third-party packet: %v", err)
+	}
+	tp := p.ThirdParty
+	if tp == nil {
+		t.Fatal("third-party packet is nil")
+	}
+	if tp.Type != PacketTypeWx || tp.Wx == nil {
+		t.Errorf("inner type = %q, want %q", tp.Type, PacketTypeWx)
+	}
+	if tp.Latitude == nil || tp.Longitude == nil {
+		t.Fatal("inner position is nil")
+	}
+	if p.Latitude != nil {
+		t.Errorf("outer latitude = %f, want nil", *p.Latitude)
+	}
+}
+
+func TestThirdPartyNested(t *testing.T) {
+	inner := "N0CALL>APRS:>Hello"
+	packet := inner
+	for i := range maxThirdPartyDepth {
+		packet = "GW" + string(rune('1'+i)) + ">APRS:}" + packet
+	}
+
+	p, err := Parse(packet)
+	if err != nil {
+		t.Fatalf("failed to parse nested third-party packet: %v", err)
+	}
+	for range maxThirdPartyDepth {
+		if p.Type != PacketTypeThirdParty {
+			t.Fatalf("type = %q, want %q", p.Type, PacketTypeThirdParty)
+		}
+		p = p.ThirdParty
+	}
+	if p.OrigPacket != inner || p.Status != "Hello" {
+		t.Errorf("innermost packet = %q, status %q", p.OrigPacket, p.Status)
+	}
+
+	// One level too deep
+	_, err = Parse("GW0>APRS:}" + packet)
+	if !errors.Is(err, ErrThirdPartyDepth) {
+		t.Errorf("expected %v, got %v", ErrThirdPartyDepth, err)
+	}
+}
+
+func TestThirdPartyInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		packet  string
+		wantErr error
+	}{
+		{"no inner header", "OH2XYZ>APRS:}", ErrPacketNoBody},
+		{"no gt in inner header", "OH2XYZ>APRS:}N0CALL:>Hello", ErrSrcCallNoGT},
+		{"bad inner destination", "OH2XYZ>APRS:}N0CALL>AP*RS:>Hello", ErrDstCallNoAX25},
+		{"no inner body", "OH2XYZ>APRS:}N0CALL>APRS:", ErrPacketNoBody},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Parse(tc.packet)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+			if p.Type != PacketTypeThirdParty {
+				t.Errorf("type = %q, want %q", p.Type, PacketTypeThirdParty)
+			}
+			if p.ThirdParty == nil || !strings.HasPrefix(tc.packet, p.Header+":}"+p.ThirdParty.OrigPacket) {
+				t.Errorf("third-party packet not kept: %+v", p.ThirdParty)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package fap
+
+import "errors"
+
+// UserDefinedDecoder decodes the payload of a user-defined format packet.
+// The returned data is stored in Packet.UserDefined.Data. The decoder may
+// also set other fields of the packet, such as the position. A returned
+// error fails the parse: a *ParseError is returned as is, other errors
+// are reported with ErrUserDefInvalid.
+type UserDefinedDecoder func(p *Packet, payload string) (any, error)
+
+// parseUserDefined parses a user-defined format packet.
+// Format: {UTdata, where U is the user ID and T the packet type
+func (p *Packet) parseUserDefined(opt *options) error {
+	p.Type = PacketTypeUserDefined
+
+	ud := &UserDefined{
+		UserID:     p.Body[1],
+		PacketType: p.Body[2],
+		Payload:    p.Body[3:],
+	}
+	p.UserDefined = ud
+
+	dec := opt.userDecoders[[2]byte{ud.UserID, ud.PacketType}]
+	if dec == nil {
+		return nil
+	}
+
+	data, err := dec(p, ud.Payload)
+	if err != nil {
+		if perr, ok := errors.AsType[*ParseError](err); ok {
+			return perr
+		}
+		return p.fail(ErrUserDefInvalid, err.Error())
+	}
+	ud.Data = data
+
+	return nil
+}
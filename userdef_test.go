@@ -0,0 +1,140 @@
+package fap
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUserDefinedUnregistered(t *testing.T) {
+	p, err := Parse("N0CALL>APRS:{Q1qwerty")
+	if err != nil {
+		t.Fatalf("failed to parse user-defined packet: %v", err)
+	}
+	if p.Type != PacketTypeUserDefined {
+		t.Errorf("type = %q, want %q", p.Type, PacketTypeUserDefined)
+	}
+	ud := p.UserDefined
+	if ud == nil {
+		t.Fatal("user-defined data is nil")
+	}
+	if ud.UserID != 'Q' || ud.PacketType != '1' || ud.Payload != "qwerty" {
+		t.Errorf("user-defined = %c %c %q, want Q 1 %q", ud.UserID, ud.PacketType, ud.Payload, "qwerty")
+	}
+	if ud.Data != nil {
+		t.Errorf("data = %v, want nil", ud.Data)
+	}
+}
+
+// sensorReading is the decoded data of the test sensor format.
+type sensorReading struct {
+	Temp     float64
+	Humidity int
+}
+
+// decodeSensor decodes a test sensor format: {XStemp,humidity
+func decodeSensor(p *Packet, payload string) (any, error) {
+	temp, hum, ok := strings.Cut(payload, ",")
+	if !ok {
+		return nil, fmt.Errorf("missing humidity")
+	}
+	t, err := strconv.ParseFloat(temp, 64)
+	if err != nil {
+		return nil, err
+	}
+	h, err := strconv.Atoi(hum)
+	if err != nil {
+		return nil, &ParseError{Code: "sensor_hum", Msg: err.Error()}
+	}
+	p.Comment = "sensor"
+	return sensorReading{Temp: t, Humidity: h}, nil
+}
+
+func TestUserDefinedDecoder(t *testing.T) {
+	opt := WithUserDefinedDecoder('X', 'S', decodeSensor)
+
+	p, err := Parse("N0CALL>APRS:{XS21.5,40", opt)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if p.Type != PacketTypeUserDefined {
+		t.Errorf("type = %q, want %q", p.Type, PacketTypeUserDefined)
+	}
+	got, ok := p.UserDefined.Data.(sensorReading)
+	if !ok {
+		t.Fatalf("data = %#v, want sensorReading", p.UserDefined.Data)
+	}
+	if got.Temp != 21.5 || got.Humidity != 40 {
+		t.Errorf("data = %+v", got)
+	}
+	if p.Comment != "sensor" {
+		t.Errorf("comment = %q, want %q", p.Comment, "sensor")
+	}
+
+	// Other packet types of the same user are not decoded
+	p, err = Parse("N0CALL>APRS:{XT21.5,40", opt)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if p.UserDefined == nil || p.UserDefined.Data != nil {
+		t.Errorf("user-defined = %+v, want no data", p.UserDefined)
+	}
+
+	// Encapsulated packets use the same decoders
+	p, err = Parse("GW>APRS:}N0CALL>APRS,TCPIP,GW*:{XS-3,95", opt)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if got, ok := p.ThirdParty.UserDefined.Data.(sensorReading); !ok || got.Temp != -3 {
+		t.Errorf("third-party data = %#v", p.ThirdParty.UserDefined.Data)
+	}
+}
+
+func TestUserDefinedDecoderErrors(t *testing.T) {
+	opt := WithUserDefinedDecoder('X', 'S', decodeSensor)
+
+	_, err := Parse("N0CALL>APRS:{XS21.5", opt)
+	if !errors.Is(err, ErrUserDefInvalid) {
+		t.Errorf("expected %v, got %v", ErrUserDefInvalid, err)
+	}
+
+	_, err = Parse("N0CALL>APRS:{XS21.5,x", opt)
+	if !errors.Is(err, &ParseError{Code: "sensor_hum"}) {
+		t.Errorf("expected decoder's ParseError, got %v", err)
+	}
+}
+
+func TestUserDefinedShort(t *testing.T) {
+	p, _ := Parse("N0CALL>APRS:{Q")
+	if p.Type == PacketTypeUserDefined {
+		t.Errorf("too short packet parsed as user-defined")
+	}
+}
+
+func TestUserDefinedExperimental(t *testing.T) {
+	packet := "ASDF>DSALK,OH2RDG*,WIDE:{{ experimental format"
+
+	p, err := Parse(packet)
+	if err != nil {
+		t.Fatalf("failed to parse experimental packet: %v", err)
+	}
+	if p.Type != PacketTypeUserDefined {
+		t.Errorf("type = %q, want %q", p.Type, PacketTypeUserDefined)
+	}
+	if ud := p.UserDefined; ud == nil || ud.UserID != '{' || ud.PacketType != ' ' || ud.Payload != "experimental format" {
+		t.Errorf("user-defined = %+v, want { and space with %q", ud, "experimental format")
+	}
+
+	dec := func(p *Packet, payload string) (any, error) {
+		return strings.ToUpper(payload), nil
+	}
+	p, err = Parse("N0CALL>APRS:{{{abc", WithUserDefinedDecoder('{', '{', dec))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if p.UserDefined == nil || p.UserDefined.Data != "ABC" {
+		t.Errorf("user-defined = %+v, want data ABC", p.UserDefined)
+	}
+}
@@ -0,0 +1,171 @@
+package fap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ReadWAV reads a PCM WAV file and returns its samples, scaled to the
+// range -1 to 1, and the sample rate. 8-bit and 16-bit files are
+// supported. Only the first channel of a multi-channel file is returned.
+func ReadWAV(r io.Reader) ([]float64, int, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, 0, &ParseError{Code: ErrWAVInvalid.Code, Msg: fmt.Sprintf("failed to read RIFF header: %v", err)}
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, 0, &ParseError{Code: ErrWAVInvalid.Code, Msg: "not a RIFF WAVE file"}
+	}
+
+	var (
+		channels      int
+		sampleRate    int
+		bitsPerSample int
+		haveFormat    bool
+	)
+
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, 0, &ParseError{Code: ErrWAVInvalid.Code, Msg: "no data chunk"}
+		}
+		id := string(hdr[0:4])
+		size := int64(binary.LittleEndian.Uint32(hdr[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, 0, &ParseError{Code: ErrWAVInvalid.Code, Msg: "format chunk too short"}
+			}
+			var fmtChunk [16]byte
+			if _, err := io.ReadFull(r, fmtChunk[:]); err != nil {
+				return nil, 0, &ParseError{Code: ErrWAVInvalid.Code, Msg: "format chunk too short"}
+			}
+			// Skip the rest of the chunk, including the pad byte
+			if _, err := io.CopyN(io.Discard, r, size-16+size%2); err != nil {
+				return nil, 0, &ParseError{Code: ErrWAVInvalid.Code, Msg: "format chunk too short"}
+			}
+
+			format := binary.LittleEndian.Uint16(fmtChunk[0:2])
+			channels = int(binary.LittleEndian.Uint16(fmtChunk[2:4]))
+			sampleRate = int(binary.LittleEndian.Uint32(fmtChunk[4:8]))
+			bitsPerSample = int(binary.LittleEndian.Uint16(fmtChunk[14:16]))
+
+			// 0xFFFE is WAVE_FORMAT_EXTENSIBLE, assumed to contain PCM
+			if format != 1 && format != 0xFFFE {
+				return nil, 0, &ParseError{Code: ErrWAVInvalid.Code, Msg: fmt.Sprintf("unsupported format %d, only PCM is supported", format)}
+			}
+			if bitsPerSample != 8 && bitsPerSample != 16 {
+				return nil, 0, &ParseError{Code: ErrWAVInvalid.Code, Msg: fmt.Sprintf("unsupported sample size: %d bits", bitsPerSample)}
+			}
+			if channels < 1 || sampleRate < 1 {
+				return nil, 0, &ParseError{Code: ErrWAVInvalid.Code, Msg: "invalid channel count or sample rate"}
+			}
+			haveFormat = true
+
+		case "data":
+			if !haveFormat {
+				return nil, 0, &ParseError{Code: ErrWAVInvalid.Code, Msg: "data chunk before format chunk"}
+			}
+			// A truncated data chunk is accepted, as written by some
+			// recorders which are stopped abruptly
+			data, err := io.ReadAll(io.LimitReader(r, size))
+			if err != nil {
+				return nil, 0, err
+			}
+			return wavSamples(data, channels, bitsPerSample/8), sampleRate, nil
+
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return nil, 0, &ParseError{Code: ErrWAVInvalid.Code, Msg: fmt.Sprintf("truncated %q chunk", id)}
+			}
+		}
+	}
+}
+
+// wavSamples converts PCM data to samples of the first channel.
+func wavSamples(data []byte, channels, width int) []float64 {
+	frame := channels * width
+	samples := make([]float64, 0, len(data)/frame)
+	for i := 0; i+frame <= len(data); i += frame {
+		if width == 1 {
+			// 8-bit samples are unsigned
+			samples = append(samples, (float64(data[i])-128)/128)
+		} else {
+			samples = append(samples, float64(int16(binary.LittleEndian.Uint16(data[i:])))/32768)
+		}
+	}
+	return samples
+}
+
+// PCM16 converts samples in the range -1 to 1 to 16-bit signed
+// little-endian PCM, as written to a sound card. Samples out of range are
+// clipped.
+func PCM16(samples []float64) []byte {
+	data := make([]byte, 2*len(samples))
+	for i, s := range samples {
+		s = max(-1, min(1, s))
+		binary.LittleEndian.PutUint16(data[2*i:], uint16(int16(math.Round(s*32767))))
+	}
+	return data
+}
+
+// WriteWAV writes samples in the range -1 to 1 as a 16-bit mono PCM WAV
+// file.
+func WriteWAV(w io.Writer, samples []float64, sampleRate int) error {
+	data := PCM16(samples)
+
+	hdr := make([]byte, 44)
+	copy(hdr[0:], "RIFF")
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(36+len(data)))
+	copy(hdr[8:], "WAVE")
+	copy(hdr[12:], "fmt ")
+	binary.LittleEndian.PutUint32(hdr[16:], 16)
+	binary.LittleEndian.PutUint16(hdr[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(hdr[22:], 1) // mono
+	binary.LittleEndian.PutUint32(hdr[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(hdr[28:], uint32(2*sampleRate))
+	binary.LittleEndian.PutUint16(hdr[32:], 2)
+	binary.LittleEndian.PutUint16(hdr[34:], 16)
+	copy(hdr[36:], "data")
+	binary.LittleEndian.PutUint32(hdr[40:], uint32(len(data)))
+
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// DecodeWAV demodulates 1200 bit/s AFSK audio from a WAV file and returns
+// the packets decoded from it. Frames with a valid FCS which cannot be
+// parsed are left out of the packets, and their errors are returned joined
+// with errors.Join, along with the packets which could be parsed; use
+// AFSKDemodulator and DecodeAX25 directly to see the failed packets.
+func DecodeWAV(r io.Reader, opts ...Option) ([]*Packet, error) {
+	samples, sampleRate, err := ReadWAV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := NewAFSKDemodulator(sampleRate)
+	if err != nil {
+		return nil, err
+	}
+
+	var packets []*Packet
+	var errs []error
+	for _, frame := range d.Demodulate(samples) {
+		p, err := DecodeAX25(frame, opts...)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		packets = append(packets, p)
+	}
+
+	return packets, errors.Join(errs...)
+}
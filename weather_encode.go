@@ -0,0 +1,240 @@
+package fap
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// EncodeWeatherOpts contains optional parameters for EncodeWeather.
+// The embedded position options apply to the position part of the report.
+// A comment is appended after the weather data and the software identifier.
+type EncodeWeatherOpts struct {
+	EncodePositionOpts
+	Compressed bool // use compressed position format
+}
+
+// EncodeWeather creates a position report with weather data.
+// lat/lon are in decimal degrees and symbol is a 2-character string whose
+// symbol code must be '_' (weather station), e.g. "/_".
+// Weather values are in the units reported by the parser. TempIn and
+// HumidityIn cannot be represented in the format and are ignored.
+func EncodeWeather(lat, lon float64, wx *Weather, symbol string, opts *EncodeWeatherOpts) (string, error) {
+	if opts == nil {
+		opts = &EncodeWeatherOpts{}
+	}
+	if wx == nil {
+		return "", &ParseError{Code: ErrWxEncInvalid.Code, Msg: "no weather data"}
+	}
+	if len(symbol) != 2 || symbol[1] != '_' {
+		return "", &ParseError{Code: ErrWxEncInvalid.Code, Msg: fmt.Sprintf("weather report must use a weather station symbol, got %q", symbol)}
+	}
+
+	// The compressed parser does not read wind from the cs bytes, so wind
+	// is always sent in the weather data.
+	data, err := encodeWeatherData(wx, !opts.Compressed)
+	if err != nil {
+		return "", err
+	}
+
+	posOpts := opts.EncodePositionOpts
+	posOpts.Comment = data + wx.Software + opts.Comment
+
+	var body string
+	if opts.Compressed {
+		body, err = encodeCompressedCoords(lat, lon, nil, nil, nil, symbol, &posOpts)
+	} else {
+		body, err = encodeUncompressedCoords(lat, lon, nil, nil, nil, symbol, &posOpts)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return positionPrefix(&posOpts) + body, nil
+}
+
+// EncodeWeatherPositionless creates a positionless weather report
+// (_MMDDHHMM followed by the weather data and the software identifier).
+// The report is timestamped with ts in UTC, or the current time if ts is
+// zero.
+func EncodeWeatherPositionless(wx *Weather, ts time.Time) (string, error) {
+	if wx == nil {
+		return "", &ParseError{Code: ErrWxEncInvalid.Code, Msg: "no weather data"}
+	}
+
+	data, err := encodeWeatherData(wx, false)
+	if err != nil {
+		return "", err
+	}
+
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	ts = ts.UTC()
+
+	return fmt.Sprintf("_%02d%02d%02d%02d%s%s", int(ts.Month()), ts.Day(), ts.Hour(), ts.Minute(), data, wx.Software), nil
+}
+
+// encodeWeatherData creates the weather data fields. Wind direction, wind
+// speed, gust and temperature are always present, filled with dots if
+// unknown. With windPrefix set the wind is sent as DDD/SSS, as in a position
+// report, otherwise as cDDDsSSS.
+func encodeWeatherData(wx *Weather, windPrefix bool) (string, error) {
+	if wx.Software != "" && !isSoftwareID(wx.Software) {
+		return "", &ParseError{Code: ErrWxEncInvalid.Code, Msg: fmt.Sprintf("invalid software identifier: %q", wx.Software)}
+	}
+
+	var sb strings.Builder
+
+	dir, err := wxField("wind direction", wx.WindDirection, 1, 0, 360, 3)
+	if err != nil {
+		return "", err
+	}
+	speed, err := wxField("wind speed", wx.WindSpeed, 1/0.44704, 0, 999, 3)
+	if err != nil {
+		return "", err
+	}
+	if windPrefix {
+		sb.WriteString(dir + "/" + speed)
+	} else {
+		sb.WriteString("c" + dir + "s" + speed)
+	}
+
+	gust, err := wxField("wind gust", wx.WindGust, 1/0.44704, 0, 999, 3)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString("g" + gust)
+
+	// Temperature in Fahrenheit
+	var tempF *float64
+	if wx.Temp != nil {
+		tempF = new(*wx.Temp*9/5 + 32)
+	}
+	temp, err := wxField("temperature", tempF, 1, -99, 999, 3)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString("t" + temp)
+
+	// Optional fields are left out when unknown
+	rain := []struct {
+		id    string
+		name  string
+		v     *float64
+		scale float64
+		lo    int
+		hi    int
+		width int
+	}{
+		{"r", "rain last hour", wx.Rain1h, 1 / 0.254, 0, 999, 3},
+		{"p", "rain last 24 hours", wx.Rain24h, 1 / 0.254, 0, 999, 3},
+		{"P", "rain since midnight", wx.RainMidnight, 1 / 0.254, 0, 999, 3},
+	}
+	for _, f := range rain {
+		if f.v == nil {
+			continue
+		}
+		s, err := wxField(f.name, f.v, f.scale, f.lo, f.hi, f.width)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(f.id + s)
+	}
+
+	// Humidity 100% is sent as 00
+	if wx.Humidity != nil {
+		h := *wx.Humidity
+		if h < 1 || h > 100 {
+			return "", &ParseError{Code: ErrWxEncInvalid.Code, Msg: fmt.Sprintf("humidity out of range: %d", h)}
+		}
+		fmt.Fprintf(&sb, "h%02d", h%100)
+	}
+
+	// Pressure in tenths of millibars
+	if wx.Pressure != nil {
+		s, err := wxField("pressure", wx.Pressure, 10, 0, 99999, 5)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("b" + s)
+	}
+
+	// Luminosity of 1000 W/m^2 and over is sent as l, offset by 1000
+	if wx.Luminosity != nil {
+		l := *wx.Luminosity
+		switch {
+		case l >= 0 && l <= 999:
+			fmt.Fprintf(&sb, "L%03d", l)
+		case l >= 1000 && l <= 1999:
+			fmt.Fprintf(&sb, "l%03d", l-1000)
+		default:
+			return "", &ParseError{Code: ErrWxEncInvalid.Code, Msg: fmt.Sprintf("luminosity out of range: %d", l)}
+		}
+	}
+
+	if wx.Snow24h != nil {
+		s, err := wxField("snowfall", wx.Snow24h, 1/0.254, 0, 999, 3)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("Os" + s)
+	}
+
+	// Water level in tenths of a foot
+	if wx.WaterLevel != nil {
+		s, err := wxField("water level", wx.WaterLevel, 10/0.3048, -999, 9999, 4)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("F" + s)
+	}
+
+	if wx.Radiation != nil {
+		s, err := encodeWxRadiation(*wx.Radiation)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("X" + s)
+	}
+
+	if wx.BatteryVoltage != nil {
+		s, err := wxField("battery voltage", wx.BatteryVoltage, 10, 0, 999, 3)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("V" + s)
+	}
+
+	return sb.String(), nil
+}
+
+// wxField scales and rounds a weather value and formats it as a
+// zero-padded field of the given width. A nil value is sent as dots.
+func wxField(name string, v *float64, scale float64, lo, hi, width int) (string, error) {
+	if v == nil {
+		return strings.Repeat(".", width), nil
+	}
+	n := int(math.Round(*v * scale))
+	if n < lo || n > hi {
+		return "", &ParseError{Code: ErrWxEncInvalid.Code, Msg: fmt.Sprintf("%s out of range: %f", name, *v)}
+	}
+	return fmt.Sprintf("%0*d", width, n), nil
+}
+
+// encodeWxRadiation encodes a radiation level in nSv/hour in the resistor
+// code form used by the X field: two significant digits followed by a
+// power of ten exponent.
+func encodeWxRadiation(v float64) (string, error) {
+	if v < 0 {
+		return "", &ParseError{Code: ErrWxEncInvalid.Code, Msg: fmt.Sprintf("radiation out of range: %f", v)}
+	}
+	for exp := range 10 {
+		sig := int(math.Round(v / math.Pow(10, float64(exp))))
+		if sig <= 99 {
+			return fmt.Sprintf("%02d%d", sig, exp), nil
+		}
+	}
+	return "", &ParseError{Code: ErrWxEncInvalid.Code, Msg: fmt.Sprintf("radiation out of range: %f", v)}
+}
@@ -0,0 +1,205 @@
+package fap
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// wxFloatEqual compares optional weather values with a tolerance.
+func wxFloatEqual(a, b *float64, tolerance float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return math.Abs(*a-*b) <= tolerance
+}
+
+// wxIntEqual compares optional integer weather values.
+func wxIntEqual(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// compareWeather reports differences between two weather reports which
+// survive a round trip through the encoder.
+func compareWeather(t *testing.T, got, want *Weather) {
+	t.Helper()
+	floats := []struct {
+		name      string
+		got, want *float64
+		tolerance float64
+	}{
+		{"wind direction", got.WindDirection, want.WindDirection, 0.5},
+		{"wind speed", got.WindSpeed, want.WindSpeed, 0.44704 / 2},
+		{"wind gust", got.WindGust, want.WindGust, 0.44704 / 2},
+		{"temp", got.Temp, want.Temp, 0.5 / 1.8},
+		{"rain 1h", got.Rain1h, want.Rain1h, 0.254 / 2},
+		{"rain 24h", got.Rain24h, want.Rain24h, 0.254 / 2},
+		{"rain midnight", got.RainMidnight, want.RainMidnight, 0.254 / 2},
+		{"pressure", got.Pressure, want.Pressure, 0.05},
+		{"snow 24h", got.Snow24h, want.Snow24h, 0.254 / 2},
+		{"water level", got.WaterLevel, want.WaterLevel, 0.03048 / 2},
+		{"battery voltage", got.BatteryVoltage, want.BatteryVoltage, 0.05},
+	}
+	for _, f := range floats {
+		if !wxFloatEqual(f.got, f.want, f.tolerance+1e-9) {
+			t.Errorf("%s = %v, want %v", f.name, fmtPtr(f.got), fmtPtr(f.want))
+		}
+	}
+	if !wxFloatEqual(got.Radiation, want.Radiation, 0) {
+		t.Errorf("radiation = %v, want %v", fmtPtr(got.Radiation), fmtPtr(want.Radiation))
+	}
+	if !wxIntEqual(got.Humidity, want.Humidity) {
+		t.Errorf("humidity = %v, want %v", got.Humidity, want.Humidity)
+	}
+	if !wxIntEqual(got.Luminosity, want.Luminosity) {
+		t.Errorf("luminosity = %v, want %v", got.Luminosity, want.Luminosity)
+	}
+	if got.Software != want.Software {
+		t.Errorf("software = %q, want %q", got.Software, want.Software)
+	}
+}
+
+// fmtPtr returns the value of an optional float for error messages.
+func fmtPtr(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func TestEncodeWeather(t *testing.T) {
+	p, err := Parse("OH2RDP-1>BEACON-15,WIDE2-1,qAo,OH2MQK-1:=6030.35N/02443.91E_150/002g004t039r001P002p004h00b10125XRSW")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	got, err := EncodeWeather(*p.Latitude, *p.Longitude, p.Wx, "/_", &EncodeWeatherOpts{
+		EncodePositionOpts: EncodePositionOpts{MessagingCapable: true},
+	})
+	if err != nil {
+		t.Fatalf("EncodeWeather failed: %v", err)
+	}
+	want := "=6030.35N/02443.91E_150/002g004t039r001p004P002h00b10125XRSW"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeWeatherPositionless(t *testing.T) {
+	p, err := Parse("JH9YVX>APU25N,TCPIP*,qAC,T2TOKYO3:_12032359c180s001g002t033r010p040P080#456b09860h98Os010L500")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	ts := time.Date(2025, 12, 3, 23, 59, 30, 0, time.UTC)
+	got, err := EncodeWeatherPositionless(p.Wx, ts)
+	if err != nil {
+		t.Fatalf("EncodeWeatherPositionless failed: %v", err)
+	}
+	want := "_12032359c180s001g002t033r010p040P080h98b09860L500Os010"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Unknown values
+	got, err = EncodeWeatherPositionless(&Weather{Rain1h: new(2.032), Luminosity: new(1234)}, ts)
+	if err != nil {
+		t.Fatalf("EncodeWeatherPositionless failed: %v", err)
+	}
+	want = "_12032359c...s...g...t...r008l234"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeWeatherRoundTrip(t *testing.T) {
+	packets := []string{
+		"OH2RDP-1>BEACON-15,WIDE2-1,qAo,OH2MQK-1:=6030.35N/02443.91E_150/002g004t039r001P002p004h00b10125XRSW",
+		"OH2GAX>APU25N,TCPIP*,qAC,OH2GAX:@101317z6024.78N/02503.97E_156/001g005t038r000p000P000h91b10093/type ?sade for more wx info",
+		"N0CALL>APJLSX,TCPIP*,qAS,KG4EXY:@061750z3849.10N/07725.10W_.../...g...t...r008p011P011b.....h..",
+		"JH9YVX>APU25N,TCPIP*,qAC,T2TOKYO3:_12032359c180s001g002t033r010p040P080#456b09860h98Os010L500",
+		"N0CALL>APRS,TCPIP*:_12032359c000s000g000t-05F-042",
+		"N0CALL>APRS,TCPIP*:_12032359c000s000g000t033X123V138l050",
+	}
+
+	for _, packet := range packets {
+		t.Run(packet, func(t *testing.T) {
+			p, err := Parse(packet)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if p.Wx == nil {
+				t.Fatal("wx is nil")
+			}
+
+			var encoded []string
+			if p.Latitude != nil {
+				for _, compressed := range []bool{false, true} {
+					body, err := EncodeWeather(*p.Latitude, *p.Longitude, p.Wx, "/_", &EncodeWeatherOpts{
+						EncodePositionOpts: EncodePositionOpts{Comment: p.Comment},
+						Compressed:         compressed,
+					})
+					if err != nil {
+						t.Fatalf("EncodeWeather failed: %v", err)
+					}
+					encoded = append(encoded, body)
+				}
+			} else {
+				body, err := EncodeWeatherPositionless(p.Wx, time.Time{})
+				if err != nil {
+					t.Fatalf("EncodeWeatherPositionless failed: %v", err)
+				}
+				encoded = append(encoded, body)
+			}
+
+			for _, body := range encoded {
+				rp, err := Parse("N0CALL>APRS:" + body)
+				if err != nil {
+					t.Fatalf("failed to parse %q: %v", body, err)
+				}
+				if rp.Type != PacketTypeWx || rp.Wx == nil {
+					t.Fatalf("%q: type = %q, want wx", body, rp.Type)
+				}
+				compareWeather(t, rp.Wx, p.Wx)
+				if rp.Comment != p.Comment {
+					t.Errorf("%q: comment = %q, want %q", body, rp.Comment, p.Comment)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeWeatherErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		wx      *Weather
+		symbol  string
+		wantErr error
+	}{
+		{"nil weather", nil, "/_", ErrWxEncInvalid},
+		{"not a weather symbol", &Weather{}, "/>", ErrWxEncInvalid},
+		{"invalid symbol table", &Weather{}, "x_", ErrPosEncInvalid},
+		{"wind direction too large", &Weather{WindDirection: new(361.0)}, "/_", ErrWxEncInvalid},
+		{"negative wind speed", &Weather{WindSpeed: new(-1.0)}, "/_", ErrWxEncInvalid},
+		{"temperature too low", &Weather{Temp: new(-80.0)}, "/_", ErrWxEncInvalid},
+		{"humidity zero", &Weather{Humidity: new(0)}, "/_", ErrWxEncInvalid},
+		{"pressure too large", &Weather{Pressure: new(10000.0)}, "/_", ErrWxEncInvalid},
+		{"luminosity too large", &Weather{Luminosity: new(2000)}, "/_", ErrWxEncInvalid},
+		{"negative radiation", &Weather{Radiation: new(-1.0)}, "/_", ErrWxEncInvalid},
+		{"water level too low", &Weather{WaterLevel: new(-31.0)}, "/_", ErrWxEncInvalid},
+		{"invalid software", &Weather{Software: "not valid"}, "/_", ErrWxEncInvalid},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := EncodeWeather(60, 24, tc.wx, tc.symbol, nil)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}